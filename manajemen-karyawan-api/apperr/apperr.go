@@ -0,0 +1,169 @@
+// Package apperr defines the application's error type. Every error reaching
+// a client carries a stable machine-readable Code, which fixes the HTTP
+// status, and is rendered as an RFC 7807 problem document by
+// middleware.ErrorMiddleware. Codes are part of the API contract: add new
+// ones freely, but never rename or repurpose an existing one.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeInvalidRequest     Code = "invalid_request"
+	CodeValidationFailed   Code = "validation_failed"
+	CodeUnauthenticated    Code = "unauthenticated"
+	CodeTokenExpired       Code = "token_expired"
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeConflict           Code = "conflict"
+	CodePreconditionFailed Code = "precondition_failed"
+	CodeAlreadyClockedIn   Code = "already_clocked_in"
+	CodeNotClockedIn       Code = "not_clocked_in"
+	CodeInternal           Code = "internal_error"
+	CodeUnavailable        Code = "service_unavailable"
+)
+
+var statusOf = map[Code]int{
+	CodeInvalidRequest:     http.StatusBadRequest,
+	CodeValidationFailed:   http.StatusBadRequest,
+	CodeUnauthenticated:    http.StatusUnauthorized,
+	CodeTokenExpired:       http.StatusUnauthorized,
+	CodeInvalidCredentials: http.StatusUnauthorized,
+	CodeForbidden:          http.StatusForbidden,
+	CodeNotFound:           http.StatusNotFound,
+	CodeMethodNotAllowed:   http.StatusMethodNotAllowed,
+	CodeConflict:           http.StatusConflict,
+	CodePreconditionFailed: http.StatusPreconditionFailed,
+	CodeAlreadyClockedIn:   http.StatusConflict,
+	CodeNotClockedIn:       http.StatusConflict,
+	CodeInternal:           http.StatusInternalServerError,
+	CodeUnavailable:        http.StatusServiceUnavailable,
+}
+
+// Status is the HTTP status for the code; unknown codes are server errors.
+func (c Code) Status() int {
+	if s, ok := statusOf[c]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError describes one invalid request field. Field uses the JSON name
+// the client sent.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code Code
+	// Detail is shown to the client, so it must not leak internals.
+	Detail string
+	Fields []FieldError
+	// Err is the underlying cause, logged but never sent to the client.
+	Err error
+}
+
+func New(code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+// Wrap attaches a client-facing code and detail to err.
+func Wrap(err error, code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail, Err: err}
+}
+
+func BadRequest(detail string) *Error { return New(CodeInvalidRequest, detail) }
+
+func Unauthorized(detail string) *Error { return New(CodeUnauthenticated, detail) }
+
+func Forbidden(detail string) *Error { return New(CodeForbidden, detail) }
+
+func NotFound(detail string) *Error { return New(CodeNotFound, detail) }
+
+func Conflict(detail string) *Error { return New(CodeConflict, detail) }
+
+// Internal wraps an unexpected failure; detail says what the request was
+// trying to do, e.g. "failed to fetch employees". An err that already
+// carries a code, such as repository.ErrNotFound, is returned as is so its
+// status survives.
+func Internal(err error, detail string) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Wrap(err, CodeInternal, detail)
+}
+
+// Invalid reports a single bad field.
+func Invalid(field, code, message string) *Error {
+	return Validation(FieldError{Field: field, Code: code, Message: message})
+}
+
+// Validation reports one or more bad fields. The detail repeats the first
+// message so clients that only show detail still say something useful.
+func Validation(fields ...FieldError) *Error {
+	detail := "request validation failed"
+	if len(fields) == 1 {
+		detail = fields[0].Message
+	}
+	return &Error{Code: CodeValidationFailed, Detail: detail, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func (e *Error) Status() int { return e.Code.Status() }
+
+// From returns err as an *Error, treating anything without a code as an
+// internal error.
+func From(err error) *Error {
+	return Internal(err, "internal server error")
+}
+
+// Problem is an RFC 7807 problem document. Code, RequestID and Errors are
+// extension members.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Error repeats Detail on /api (v1) responses, whose clients read the
+	// message from "error" since before problem documents.
+	Error string `json:"error,omitempty"`
+}
+
+// ContentType is the media type problem documents are served with.
+const ContentType = "application/problem+json"
+
+// Problem renders e for the request path instance.
+func (e *Error) Problem(instance string) Problem {
+	status := e.Status()
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Detail,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"manajemen-karyawan-api/service/attendance"
+)
+
+func attendanceCmd(ctx context.Context, args []string) error {
+	return subcommand("attendance", args, map[string]func(ctx context.Context, args []string) error{
+		"close-open": closeOpen,
+	})(ctx)
+}
+
+// closeOpen clocks out attendances left open on earlier days.
+func closeOpen(ctx context.Context, args []string) error {
+	fs := newFlagSet("attendance close-open")
+	before := fs.String("before", "", "close attendances clocked in before this date (YYYY-MM-DD, default today)")
+	dryRun := fs.Bool("dry-run", false, "only list the attendances that would be closed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	svc := attendance.NewService(store(), cfg.Location())
+	cutoff, _ := svc.DayRange(time.Now())
+	if *before != "" {
+		var err error
+		if cutoff, err = svc.ParseDate(*before); err != nil {
+			return fmt.Errorf("attendance close-open: --before %w", err)
+		}
+	}
+
+	closed, err := svc.CloseOpen(ctx, cutoff, actor, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	loc := svc.Location()
+	for _, c := range closed {
+		fmt.Printf("%s  %s  in %s  out %s\n", c.AttendanceID, c.EmployeeID,
+			c.ClockIn.In(loc).Format("2006-01-02 15:04:05"), c.ClockOut.In(loc).Format("2006-01-02 15:04:05"))
+	}
+	if *dryRun {
+		fmt.Printf("%d open attendance(s) would be closed\n", len(closed))
+	} else {
+		fmt.Printf("%d open attendance(s) closed\n", len(closed))
+	}
+	return nil
+}
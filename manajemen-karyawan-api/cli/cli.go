@@ -0,0 +1,190 @@
+// Package cli implements the subcommands of the main binary: the HTTP server
+// plus the maintenance tasks operators would otherwise do by hand in SQL.
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/tracing"
+)
+
+// actor is recorded in created_by/updated_by and the audit log for changes
+// made from the command line.
+const actor = "system"
+
+// cfg is the configuration loaded by Run for the running command.
+var cfg *config.Config
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"serve":      {"serve [--port PORT]", serve},
+		"migrate":    {"migrate up | down [N] | status | baseline [VERSION]", migrate},
+		"seed":       {"seed [--password PASSWORD]", seed},
+		"user":       {"user create-admin | reset-password --employee-id CODE [...]", user},
+		"attendance": {"attendance close-open [--before YYYY-MM-DD] [--dry-run]", attendanceCmd},
+		"report":     {"report monthly [--month YYYY-MM] [--format csv] [--output FILE]", report},
+	}
+}
+
+// Run executes the subcommand named by args[0], defaulting to serve. Global
+// flags such as --config come before the subcommand. Every command except
+// help loads and validates the configuration, sets up tracing and opens the
+// database first.
+// The command's context is cancelled on SIGINT or SIGTERM, and the database
+// is closed once it returns.
+func Run(args []string) error {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
+		usage(os.Stdout)
+		return nil
+	}
+
+	var err error
+	cfg, args, err = config.Load(args)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Flush traces error", "error", err)
+		}
+	}()
+
+	if err := config.InitDB(cfg); err != nil {
+		return err
+	}
+	defer func() {
+		if err := config.DB.Close(); err != nil {
+			slog.Error("Close database error", "error", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cmd.run(ctx, args)
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: manajemen-karyawan-api [--config FILE] [--env ENV] [--port PORT] [--db-driver DRIVER] [--db-dsn DSN] [--timezone TZ] <command> [arguments]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+// subcommand dispatches args[0] to one of subs.
+func subcommand(group string, args []string, subs map[string]func(ctx context.Context, args []string) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if len(args) == 0 {
+			return fmt.Errorf("%s: missing subcommand (%s)", group, strings.Join(sortedKeys(subs), ", "))
+		}
+		run, ok := subs[args[0]]
+		if !ok {
+			return fmt.Errorf("%s: unknown subcommand %q (%s)", group, args[0], strings.Join(sortedKeys(subs), ", "))
+		}
+		return run(ctx, args[1:])
+	}
+}
+
+func sortedKeys(m map[string]func(ctx context.Context, args []string) error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+func store() repository.Store {
+	return repository.NewStore(config.DB, dbDialect(), cfg.Location())
+}
+
+// dbDialect returns the dialect of the configured driver, which Load has
+// already validated.
+func dbDialect() dialect.Dialect {
+	d, _ := cfg.Dialect()
+	return d
+}
+
+// recordAudit writes an audit log entry attributed to the CLI actor.
+func recordAudit(ctx context.Context, tx repository.Store, entity string, entityID string, action string, changes map[string]model.AuditChange) error {
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	return tx.Audit().Record(ctx, model.AuditLog{
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Actor:     actor,
+		Changes:   payload,
+		CreatedAt: time.Now(),
+	})
+}
+
+// randomPassword is used when no password is given on the command line.
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/migrations"
+)
+
+func migrate(ctx context.Context, args []string) error {
+	return subcommand("migrate", args, map[string]func(ctx context.Context, args []string) error{
+		"up":       func(ctx context.Context, _ []string) error { return migrateUp(ctx) },
+		"down":     migrateDown,
+		"status":   migrateStatus,
+		"baseline": migrateBaseline,
+	})(ctx)
+}
+
+func migrateUp(ctx context.Context) error {
+	m, err := migrations.New(config.DB, dbDialect())
+	if err != nil {
+		return err
+	}
+
+	// Databases set up from the README schema have the tables but no
+	// schema_migrations rows yet
+	adopted, err := m.Adopt(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mig := range adopted {
+		slog.Info("Adopted existing schema", "version", mig.Version, "name", mig.Name)
+	}
+
+	applied, err := m.Up(ctx)
+	for _, mig := range applied {
+		slog.Info("Applied migration", "version", mig.Version, "name", mig.Name)
+	}
+	if err == nil && len(applied) == 0 {
+		slog.Info("Schema is up to date")
+	}
+	return err
+}
+
+func migrateDown(ctx context.Context, args []string) error {
+	steps := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("migrate down: invalid step count %q", args[0])
+		}
+		steps = n
+	}
+
+	m, err := migrations.New(config.DB, dbDialect())
+	if err != nil {
+		return err
+	}
+
+	reverted, err := m.Down(ctx, steps)
+	for _, mig := range reverted {
+		slog.Info("Reverted migration", "version", mig.Version, "name", mig.Name)
+	}
+	return err
+}
+
+// migrateBaseline marks migrations as applied without running them: up to
+// VERSION when given, otherwise the versions found in the database.
+func migrateBaseline(ctx context.Context, args []string) error {
+	m, err := migrations.New(config.DB, dbDialect())
+	if err != nil {
+		return err
+	}
+
+	var stamped []migrations.Migration
+	if len(args) > 0 {
+		version, convErr := strconv.Atoi(args[0])
+		if convErr != nil {
+			return fmt.Errorf("migrate baseline: invalid version %q", args[0])
+		}
+		stamped, err = m.Baseline(ctx, version)
+	} else {
+		stamped, err = m.Adopt(ctx)
+	}
+	for _, mig := range stamped {
+		slog.Info("Marked migration as applied", "version", mig.Version, "name", mig.Name)
+	}
+	if err == nil && len(stamped) == 0 {
+		slog.Info("Nothing to mark as applied")
+	}
+	return err
+}
+
+func migrateStatus(ctx context.Context, _ []string) error {
+	m, err := migrations.New(config.DB, dbDialect())
+	if err != nil {
+		return err
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-30s %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
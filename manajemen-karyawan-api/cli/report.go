@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/service/attendance"
+)
+
+func report(ctx context.Context, args []string) error {
+	return subcommand("report", args, map[string]func(ctx context.Context, args []string) error{
+		"monthly": monthlyReport,
+	})(ctx)
+}
+
+func monthlyReport(ctx context.Context, args []string) error {
+	fs := newFlagSet("report monthly")
+	month := fs.String("month", "", "month to report (YYYY-MM, default current month)")
+	format := fs.String("format", "csv", "output format (csv)")
+	output := fs.String("output", "", "write to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" {
+		return fmt.Errorf("report monthly: unsupported format %q", *format)
+	}
+
+	svc := attendance.NewService(store(), cfg.Location())
+	start := time.Now().In(svc.Location())
+	if *month != "" {
+		var err error
+		if start, err = time.ParseInLocation("2006-01", *month, svc.Location()); err != nil {
+			return fmt.Errorf("report monthly: --month must be YYYY-MM")
+		}
+	}
+
+	rows, err := svc.MonthlyReport(ctx, start)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeMonthlyCSV(w, rows)
+}
+
+func writeMonthlyCSV(w io.Writer, rows []model.EmployeeMonthlyReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"employee_id", "employee_name", "departement", "days_present", "late", "clock_out", "early_leave", "hours_worked"})
+	for _, r := range rows {
+		cw.Write([]string{
+			r.EmployeeID,
+			r.EmployeeName,
+			r.DepartementName,
+			strconv.Itoa(r.DaysPresent),
+			strconv.Itoa(r.Late),
+			strconv.Itoa(r.ClockOut),
+			strconv.Itoa(r.EarlyLeave),
+			strconv.FormatFloat(r.HoursWorked, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
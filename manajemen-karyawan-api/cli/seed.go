@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+type seedEmployee struct {
+	code, name, address, departement, role string
+}
+
+var seedDepartements = []model.DepartementNode{
+	{DepartementName: "IT", MaxClockInTime: strPtr("09:00:00"), MaxClockOutTime: strPtr("17:00:00")},
+	{DepartementName: "HRD", MaxClockInTime: strPtr("08:30:00"), MaxClockOutTime: strPtr("16:30:00")},
+}
+
+var seedEmployees = []seedEmployee{
+	{"EMP001", "Dian Erwansyah", "Jl. Merdeka No. 10", "IT", model.EmployeeRoleAdmin},
+	{"EMP002", "Putra Pratama", "Jl. Mawar No. 5", "HRD", model.EmployeeRoleEmployee},
+}
+
+// seed inserts the initial departements and employees. Rows that already
+// exist (by departement name or employee code) are left untouched, so it is
+// safe to run more than once.
+func seed(ctx context.Context, args []string) error {
+	fs := newFlagSet("seed")
+	password := fs.String("password", cfg.Employee.DefaultPassword, "password of the seeded employees")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	hash, err := utils.CreatePassword(*password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return store().WithTx(ctx, func(tx repository.Store) error {
+		byName, err := tx.Departements().IDsByName(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range seedDepartements {
+			key := strings.ToLower(d.DepartementName)
+			if _, ok := byName[key]; ok {
+				fmt.Printf("Departement %s exists, skipped\n", d.DepartementName)
+				continue
+			}
+
+			d.ID = utils.GenerateID()
+			if err := tx.Departements().Create(ctx, d, actor, now); err != nil {
+				return err
+			}
+			byName[key] = d.ID
+			fmt.Printf("Departement %s created\n", d.DepartementName)
+		}
+
+		for _, e := range seedEmployees {
+			taken, err := tx.Employees().EmployeeIDTaken(ctx, e.code)
+			if err != nil {
+				return err
+			} else if taken {
+				fmt.Printf("Employee %s exists, skipped\n", e.code)
+				continue
+			}
+
+			emp := model.Employee{
+				ID:            utils.GenerateID(),
+				EmployeeID:    e.code,
+				DepartementID: byName[strings.ToLower(e.departement)],
+				Name:          e.name,
+				Address:       e.address,
+				Status:        model.EmployeeStatusActive,
+				Role:          e.role,
+				Password:      hash,
+			}
+			if err := createEmployee(ctx, tx, emp, now); err != nil {
+				return err
+			}
+			fmt.Printf("Employee %s created\n", e.code)
+		}
+		return nil
+	})
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/routes"
+	"manajemen-karyawan-api/service/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(ctx context.Context, args []string) error {
+	fs := newFlagSet("serve")
+	port := fs.String("port", cfg.App.Port, "HTTP port")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// ✅ Bring the schema up to date before serving requests
+	if cfg.DB.AutoMigrate {
+		if err := migrateUp(ctx); err != nil {
+			return err
+		}
+	}
+
+	// ✅ Initialize Gin router and register all routes
+	if cfg.Env == config.EnvProduction {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	// ✅ Load the employee search index and keep rebuilding it in the
+	// background
+	index := search.NewIndex(store())
+	if err := index.Rebuild(ctx); err != nil {
+		return fmt.Errorf("build search index: %w", err)
+	}
+	slog.Info("Search index built", "employees", index.Len())
+	go index.Run(ctx, cfg.Search.RefreshInterval.Duration())
+
+	r := gin.New()
+	if err := routes.RegisterRoutes(r, store(), index, cfg); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              ":" + *port,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.App.ReadHeaderTimeout.Duration(),
+		ReadTimeout:       cfg.App.ReadTimeout.Duration(),
+		WriteTimeout:      cfg.App.WriteTimeout.Duration(),
+		IdleTimeout:       cfg.App.IdleTimeout.Duration(),
+	}
+
+	// ✅ Start the server
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("Server is running", "port", *port)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	// ✅ Stop accepting connections and let in-flight requests finish. The
+	// shutdown deadline starts fresh because ctx is already cancelled.
+	timeout := cfg.App.ShutdownTimeout.Duration()
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("Server stopped")
+	return nil
+}
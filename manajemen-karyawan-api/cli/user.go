@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+func user(ctx context.Context, args []string) error {
+	return subcommand("user", args, map[string]func(ctx context.Context, args []string) error{
+		"create-admin":   createAdmin,
+		"reset-password": resetPassword,
+	})(ctx)
+}
+
+func createAdmin(ctx context.Context, args []string) error {
+	fs := newFlagSet("user create-admin")
+	code := fs.String("employee-id", "", "employee code, e.g. EMP100 (required)")
+	name := fs.String("name", "", "full name (required)")
+	departement := fs.String("departement", "", "departement name or ID (required)")
+	password := fs.String("password", "", "initial password; generated when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *code == "" || *name == "" || *departement == "" {
+		return errors.New("user create-admin: --employee-id, --name and --departement are required")
+	}
+
+	s := store()
+	departementID, err := resolveDepartement(ctx, s, *departement)
+	if err != nil {
+		return err
+	}
+
+	taken, err := s.Employees().EmployeeIDTaken(ctx, *code)
+	if err != nil {
+		return err
+	} else if taken {
+		return fmt.Errorf("user create-admin: employee ID %s already exists", *code)
+	}
+
+	generated := *password == ""
+	if generated {
+		if *password, err = randomPassword(); err != nil {
+			return err
+		}
+	}
+	hash, err := utils.CreatePassword(*password)
+	if err != nil {
+		return err
+	}
+
+	emp := model.Employee{
+		ID:            utils.GenerateID(),
+		EmployeeID:    *code,
+		DepartementID: departementID,
+		Name:          *name,
+		Status:        model.EmployeeStatusActive,
+		Role:          model.EmployeeRoleAdmin,
+		Password:      hash,
+	}
+	err = s.WithTx(ctx, func(tx repository.Store) error {
+		return createEmployee(ctx, tx, emp, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Admin %s created\n", emp.EmployeeID)
+	if generated {
+		fmt.Printf("Password: %s\n", *password)
+	}
+	return nil
+}
+
+func resetPassword(ctx context.Context, args []string) error {
+	fs := newFlagSet("user reset-password")
+	code := fs.String("employee-id", "", "employee code (required)")
+	password := fs.String("password", "", "new password; generated when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *code == "" {
+		return errors.New("user reset-password: --employee-id is required")
+	}
+
+	s := store()
+	emp, err := s.Employees().GetByEmployeeID(ctx, *code)
+	if err == repository.ErrNotFound {
+		return fmt.Errorf("user reset-password: employee %s not found", *code)
+	} else if err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		if *password, err = randomPassword(); err != nil {
+			return err
+		}
+	}
+	hash, err := utils.CreatePassword(*password)
+	if err != nil {
+		return err
+	}
+
+	err = s.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Employees().SetPassword(ctx, emp.ID, hash, actor, time.Now()); err != nil {
+			return err
+		}
+		// The hash itself never goes into the audit trail.
+		return recordAudit(ctx, tx, "employee", emp.ID, model.AuditActionUpdate, map[string]model.AuditChange{
+			"password": {Old: nil, New: "[reset]"},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Password of %s reset\n", emp.EmployeeID)
+	if generated {
+		fmt.Printf("Password: %s\n", *password)
+	}
+	return nil
+}
+
+// createEmployee inserts emp with its hire event and audit entry.
+func createEmployee(ctx context.Context, tx repository.Store, emp model.Employee, now time.Time) error {
+	emp.CreatedAt = now
+	emp.CreatedBy = actor
+	if err := tx.Employees().Create(ctx, emp); err != nil {
+		return err
+	}
+
+	changes := repository.DiffValues(nil, map[string]interface{}{
+		"employee_id":    emp.EmployeeID,
+		"departement_id": emp.DepartementID,
+		"name":           emp.Name,
+		"address":        emp.Address,
+		"status":         emp.Status,
+		"role":           emp.Role,
+	})
+	if err := recordAudit(ctx, tx, "employee", emp.ID, model.AuditActionCreate, changes); err != nil {
+		return err
+	}
+
+	hire := model.EmployeeEvent{
+		EmployeeID:      emp.ID,
+		EventType:       model.EmployeeEventHire,
+		EffectiveDate:   now,
+		ToDepartementID: &emp.DepartementID,
+	}
+	hire.CreatedAt = now
+	hire.CreatedBy = actor
+	return tx.Employees().CreateEvent(ctx, hire)
+}
+
+// resolveDepartement accepts a departement ID or (case-insensitive) name.
+func resolveDepartement(ctx context.Context, s repository.Store, ref string) (string, error) {
+	exists, err := s.Departements().Exists(ctx, ref)
+	if err != nil {
+		return "", err
+	} else if exists {
+		return ref, nil
+	}
+
+	byName, err := s.Departements().IDsByName(ctx)
+	if err != nil {
+		return "", err
+	}
+	if id, ok := byName[strings.ToLower(strings.TrimSpace(ref))]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("departement %q not found", ref)
+}
@@ -1,55 +1,498 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/logger"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
-var (
-	DBUser       string
-	DBPass       string
-	DBHost       string
-	DBPort       string
-	DBName       string
-	JWTSecret    string
-	AppPort      string
-	CookieDomain string
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+
+	// Insecure fallbacks that are fine on a laptop but refused in production
+	DefaultJWTSecret        = "default_jwt_secret"
+	DefaultEmployeePassword = "password123"
 )
 
-func InitConfig() {
-	DBUser = os.Getenv("DB_USER")
-	if DBUser == "" {
-		DBUser = "root"
+// Config is the complete application configuration. Values are layered:
+// built-in defaults, then the config file, then environment variables, then
+// command line flags.
+type Config struct {
+	Env       string          `yaml:"env" toml:"env"`
+	App       AppConfig       `yaml:"app" toml:"app"`
+	DB        DBConfig        `yaml:"db" toml:"db"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	CORS      CORSConfig      `yaml:"cors" toml:"cors"`
+	Metrics   MetricsConfig   `yaml:"metrics" toml:"metrics"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Timezone  string          `yaml:"timezone" toml:"timezone"`
+	Employee  EmployeeConfig  `yaml:"employee" toml:"employee"`
+	Retention RetentionConfig `yaml:"retention" toml:"retention"`
+	Search    SearchConfig    `yaml:"search" toml:"search"`
+}
+
+type AppConfig struct {
+	Port string `yaml:"port" toml:"port"`
+
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// How long in-flight requests may take to finish after SIGTERM
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// IPs or CIDRs of the reverse proxies whose X-Forwarded-For is believed
+	// for the client IP; empty means the address of the connection is used
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type DBConfig struct {
+	// Database backend: mysql, postgres or sqlite
+	Driver string `yaml:"driver" toml:"driver"`
+	// Full driver DSN; overrides host/port/user/... when set. For sqlite it
+	// is the database file path.
+	DSN      string `yaml:"dsn" toml:"dsn"`
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+
+	// Apply pending schema migrations when the server starts
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type AuthConfig struct {
+	JWTSecret    string   `yaml:"jwt_secret" toml:"jwt_secret"`
+	TokenTTL     Duration `yaml:"token_ttl" toml:"token_ttl"`
+	CookieDomain string   `yaml:"cookie_domain" toml:"cookie_domain"`
+	CookieTTL    Duration `yaml:"cookie_ttl" toml:"cookie_ttl"`
+	CookieSecure bool     `yaml:"cookie_secure" toml:"cookie_secure"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins" toml:"allow_origins"`
+}
+
+type MetricsConfig struct {
+	// Serve Prometheus metrics on /metrics
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+}
+
+type LogConfig struct {
+	// debug, info, warn or error
+	Level string `yaml:"level" toml:"level"`
+	// json or text
+	Format string `yaml:"format" toml:"format"`
+}
+
+type TracingConfig struct {
+	Enabled     bool   `yaml:"enabled" toml:"enabled"`
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+	// OTLP/HTTP collector URL, e.g. http://otel-collector:4318. When empty
+	// spans go to File, or to stdout when that is empty too.
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	File        string  `yaml:"file" toml:"file"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+type EmployeeConfig struct {
+	// Initial password of employees created through the API or an import
+	DefaultPassword string `yaml:"default_password" toml:"default_password"`
+}
+
+// RetentionConfig is the minimum age in days of soft-deleted rows before
+// they may be purged.
+type RetentionConfig struct {
+	EmployeeDays    int `yaml:"employee_days" toml:"employee_days"`
+	DepartementDays int `yaml:"departement_days" toml:"departement_days"`
+	AttendanceDays  int `yaml:"attendance_days" toml:"attendance_days"`
+}
+
+type SearchConfig struct {
+	// How often the employee search index is rebuilt from the database, to
+	// pick up changes made by the CLI or other instances. 0 disables it.
+	RefreshInterval Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+}
+
+// Duration reads "90m"-style strings from config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
 	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
 
-	DBPass = os.Getenv("DB_PASS")
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Env: EnvDevelopment,
+		App: AppConfig{
+			Port:              "8080",
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			ShutdownTimeout:   Duration(20 * time.Second),
+		},
+		DB: DBConfig{
+			Driver:          string(dialect.MySQL),
+			Host:            "127.0.0.1",
+			User:            "root",
+			Name:            "manajemen_karyawan",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			AutoMigrate:     true,
+		},
+		Auth: AuthConfig{
+			JWTSecret:    DefaultJWTSecret,
+			TokenTTL:     Duration(24 * time.Hour),
+			CookieDomain: "localhost",
+			CookieTTL:    Duration(time.Hour),
+		},
+		CORS:     CORSConfig{AllowOrigins: []string{"http://localhost:5173"}},
+		Metrics:  MetricsConfig{Enabled: true},
+		Log:      LogConfig{Level: "info", Format: "json"},
+		Tracing:  TracingConfig{ServiceName: "manajemen-karyawan-api", SampleRatio: 1},
+		Timezone: "Asia/Singapore",
+		Employee: EmployeeConfig{DefaultPassword: DefaultEmployeePassword},
+		Retention: RetentionConfig{
+			EmployeeDays:    365,
+			DepartementDays: 365,
+			AttendanceDays:  90,
+		},
+		Search: SearchConfig{RefreshInterval: Duration(5 * time.Minute)},
+	}
+}
 
-	DBHost = os.Getenv("DB_HOST")
-	if DBHost == "" {
-		DBHost = "127.0.0.1"
+// Load builds the configuration from the config file, the environment and
+// the global flags at the start of args, and validates it. It returns the
+// arguments left after the flags, i.e. the subcommand.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet("manajemen-karyawan-api", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file")
+	env := fs.String("env", "", "environment: development, staging or production")
+	port := fs.String("port", "", "HTTP port")
+	driver := fs.String("db-driver", "", "database driver: mysql, postgres or sqlite")
+	dsn := fs.String("db-dsn", "", "database DSN")
+	timezone := fs.String("timezone", "", "business timezone, e.g. Asia/Jakarta")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
 	}
 
-	DBPort = os.Getenv("DB_PORT")
-	if DBPort == "" {
-		DBPort = "3306"
+	cfg := Default()
+	if *file != "" {
+		if err := cfg.loadFile(*file); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	DBName = os.Getenv("DB_NAME")
-	if DBName == "" {
-		DBName = "manajemen_karyawan"
+	var errs []error
+	cfg.loadEnv(&errs)
+
+	// Flags override everything, but only when given explicitly
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Env = *env
+		case "port":
+			cfg.App.Port = *port
+		case "db-driver":
+			cfg.DB.Driver = *driver
+		case "db-dsn":
+			cfg.DB.DSN = *dsn
+		case "timezone":
+			cfg.Timezone = *timezone
+		}
+	})
+
+	if err := errors.Join(append(errs, cfg.Validate())...); err != nil {
+		return nil, nil, err
 	}
+	return cfg, fs.Args(), nil
+}
 
-	JWTSecret = os.Getenv("JWT_SECRET")
-	if JWTSecret == "" {
-		JWTSecret = "default_jwt_secret"
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
 	}
 
-	AppPort = os.Getenv("APP_PORT")
-	if AppPort == "" {
-		AppPort = "8080"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	case ".toml":
+		err = toml.NewDecoder(strings.NewReader(string(data))).DisallowUnknownFields().Decode(c)
+	default:
+		return fmt.Errorf("config file %s: unsupported format (want .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
 	}
+	return nil
+}
+
+func (c *Config) loadEnv(errs *[]error) {
+	envString(&c.Env, "APP_ENV")
+	envString(&c.App.Port, "APP_PORT")
+	envDuration(&c.App.ReadHeaderTimeout, "HTTP_READ_HEADER_TIMEOUT", errs)
+	envDuration(&c.App.ReadTimeout, "HTTP_READ_TIMEOUT", errs)
+	envDuration(&c.App.WriteTimeout, "HTTP_WRITE_TIMEOUT", errs)
+	envDuration(&c.App.IdleTimeout, "HTTP_IDLE_TIMEOUT", errs)
+	envDuration(&c.App.ShutdownTimeout, "SHUTDOWN_TIMEOUT", errs)
+	envList(&c.App.TrustedProxies, "TRUSTED_PROXIES")
+
+	envString(&c.DB.Driver, "DB_DRIVER")
+	envString(&c.DB.DSN, "DB_DSN")
+	envString(&c.DB.Host, "DB_HOST")
+	envString(&c.DB.Port, "DB_PORT")
+	envString(&c.DB.User, "DB_USER")
+	envString(&c.DB.Password, "DB_PASSWORD")
+	envString(&c.DB.Password, "DB_PASS")
+	envString(&c.DB.Name, "DB_NAME")
+	envInt(&c.DB.MaxOpenConns, "DB_MAX_OPEN_CONNS", errs)
+	envInt(&c.DB.MaxIdleConns, "DB_MAX_IDLE_CONNS", errs)
+	envDuration(&c.DB.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME", errs)
+	envBool(&c.DB.AutoMigrate, "DB_AUTO_MIGRATE", errs)
+
+	envString(&c.Auth.JWTSecret, "JWT_SECRET")
+	envDuration(&c.Auth.TokenTTL, "TOKEN_TTL", errs)
+	envString(&c.Auth.CookieDomain, "COOKIE_DOMAIN")
+	envDuration(&c.Auth.CookieTTL, "COOKIE_TTL", errs)
+	envBool(&c.Auth.CookieSecure, "COOKIE_SECURE", errs)
+
+	envList(&c.CORS.AllowOrigins, "CORS_ALLOW_ORIGINS")
+
+	envBool(&c.Metrics.Enabled, "METRICS_ENABLED", errs)
+	envString(&c.Log.Level, "LOG_LEVEL")
+	envString(&c.Log.Format, "LOG_FORMAT")
+
+	envBool(&c.Tracing.Enabled, "TRACING_ENABLED", errs)
+	envString(&c.Tracing.ServiceName, "OTEL_SERVICE_NAME")
+	envString(&c.Tracing.Endpoint, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	envString(&c.Tracing.File, "TRACING_FILE")
+	envFloat(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO", errs)
+
+	envString(&c.Timezone, "TIMEZONE")
+	envString(&c.Employee.DefaultPassword, "DEFAULT_EMPLOYEE_PASSWORD")
+
+	envInt(&c.Retention.EmployeeDays, "RETENTION_EMPLOYEE_DAYS", errs)
+	envInt(&c.Retention.DepartementDays, "RETENTION_DEPARTEMENT_DAYS", errs)
+	envInt(&c.Retention.AttendanceDays, "RETENTION_ATTENDANCE_DAYS", errs)
+
+	envDuration(&c.Search.RefreshInterval, "SEARCH_REFRESH_INTERVAL", errs)
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		fail("env %q must be development, staging or production", c.Env)
+	}
+
+	if n, err := strconv.Atoi(c.App.Port); err != nil || n < 1 || n > 65535 {
+		fail("app.port %q is not a valid port", c.App.Port)
+	}
+	if c.App.ReadHeaderTimeout <= 0 || c.App.ReadTimeout <= 0 || c.App.WriteTimeout <= 0 ||
+		c.App.IdleTimeout <= 0 || c.App.ShutdownTimeout <= 0 {
+		fail("app timeouts must be positive")
+	}
+	for _, proxy := range c.App.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			fail("app.trusted_proxies %q is not an IP or CIDR", proxy)
+		}
+	}
+
+	if _, err := c.Dialect(); err != nil {
+		fail("db.driver: %v", err)
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 || c.DB.ConnMaxLifetime < 0 {
+		fail("db pool sizes and lifetime must not be negative")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		fail("auth.jwt_secret must be set")
+	}
+	if c.Auth.TokenTTL <= 0 || c.Auth.CookieTTL <= 0 {
+		fail("auth.token_ttl and auth.cookie_ttl must be positive")
+	}
+
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			fail("cors.allow_origins cannot contain \"*\" because the session cookie needs credentials")
+		}
+	}
+
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		fail("log.level: %v", err)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		fail("log.format %q must be json or text", c.Log.Format)
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.ServiceName == "" {
+			fail("tracing.service_name must be set")
+		}
+		if u, err := url.Parse(c.Tracing.Endpoint); c.Tracing.Endpoint != "" && (err != nil || u.Scheme == "" || u.Host == "") {
+			fail("tracing.endpoint %q must be a URL such as http://otel-collector:4318", c.Tracing.Endpoint)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			fail("tracing.sample_ratio must be between 0 and 1")
+		}
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" {
+		fail("timezone %q is not a valid IANA timezone", c.Timezone)
+	}
+
+	if c.Employee.DefaultPassword == "" {
+		fail("employee.default_password must be set")
+	}
+
+	if c.Retention.EmployeeDays < 0 || c.Retention.DepartementDays < 0 || c.Retention.AttendanceDays < 0 {
+		fail("retention days must not be negative")
+	}
+
+	if c.Search.RefreshInterval < 0 {
+		fail("search.refresh_interval must not be negative")
+	}
+
+	if c.Env == EnvProduction {
+		if c.Auth.JWTSecret == DefaultJWTSecret || len(c.Auth.JWTSecret) < 32 {
+			fail("auth.jwt_secret must be changed from the default and be at least 32 characters in production")
+		}
+		if c.Employee.DefaultPassword == DefaultEmployeePassword {
+			fail("employee.default_password must be changed from the default in production")
+		}
+		if !c.Auth.CookieSecure {
+			fail("auth.cookie_secure must be true in production")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Dialect returns the SQL dialect of the configured driver.
+func (c *Config) Dialect() (dialect.Dialect, error) {
+	return dialect.Parse(c.DB.Driver)
+}
+
+// Logger builds the process logger described by the log section.
+func (c *Config) Logger(w io.Writer) (*slog.Logger, error) {
+	level, err := logger.ParseLevel(c.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+	return logger.New(w, c.Log.Format, level)
+}
+
+// Location returns the business timezone. Validate has already checked it.
+func (c *Config) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func envString(dst *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = v
+	}
+}
+
+// envList reads a comma separated list, replacing the whole default.
+func envList(dst *[]string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*dst = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*dst = append(*dst, item)
+			}
+		}
+	}
+}
+
+func envInt(dst *int, key string, errs *[]error) {
+	if v := os.Getenv(key); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %q is not a number", key, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func envFloat(dst *float64, key string, errs *[]error) {
+	if v := os.Getenv(key); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %q is not a number", key, v))
+			return
+		}
+		*dst = f
+	}
+}
+
+func envBool(dst *bool, key string, errs *[]error) {
+	if v := os.Getenv(key); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+			return
+		}
+		*dst = b
+	}
+}
 
-	CookieDomain = os.Getenv("COOKIE_DOMAIN")
-	if CookieDomain == "" {
-		CookieDomain = "localhost"
+func envDuration(dst *Duration, key string, errs *[]error) {
+	if v := os.Getenv(key); v != "" {
+		if err := dst.UnmarshalText([]byte(v)); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %q is not a duration (e.g. 30m, 24h)", key, v))
+		}
 	}
 }
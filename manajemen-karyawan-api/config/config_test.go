@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    []string
+		invalid bool
+	}{
+		{"", nil, false},
+		{"10.0.0.1", []string{"10.0.0.1"}, false},
+		{" 10.0.0.0/8, ::1 ,", []string{"10.0.0.0/8", "::1"}, false},
+		{"10.0.0.1,proxy.local", []string{"10.0.0.1", "proxy.local"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.env)
+			c := Default()
+			var errs []error
+			c.loadEnv(&errs)
+			if len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			if strings.Join(c.App.TrustedProxies, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("TrustedProxies = %q, want %q", c.App.TrustedProxies, tt.want)
+			}
+			err := c.Validate()
+			if tt.invalid != (err != nil && strings.Contains(err.Error(), "trusted_proxies")) {
+				t.Errorf("Validate = %v", err)
+			}
+		})
+	}
+}
+
+func TestMySQLDSNInUTC(t *testing.T) {
+	dsn := defaultDSN(dialect.MySQL, Default().DB)
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Loc != time.UTC || cfg.Params["time_zone"] != "'+00:00'" || !cfg.ParseTime {
+		t.Errorf("%s: loc %v, params %v, parseTime %v", dsn, cfg.Loc, cfg.Params, cfg.ParseTime)
+	}
+}
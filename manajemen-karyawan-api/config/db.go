@@ -1,28 +1,129 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
+	"strings"
 
+	"manajemen-karyawan-api/dialect"
+
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
-func InitDB() {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		DBUser, DBPass, DBHost, DBPort, DBName)
+// InitDB opens and pings the database described by cfg into DB. Every
+// statement runs through otelsql, so it shows up as a span under the request
+// that issued it when tracing is enabled.
+func InitDB(cfg *Config) error {
+	d, err := cfg.Dialect()
+	if err != nil {
+		return fmt.Errorf("invalid database driver: %w", err)
+	}
+
+	dsn := cfg.DB.DSN
+	if dsn == "" {
+		dsn = defaultDSN(d, cfg.DB)
+	}
+	if d == dialect.SQLite {
+		dsn = sqlitePragmas(dsn)
+	}
 
-	var err error
-	DB, err = sql.Open("mysql", dsn)
+	attrs := []attribute.KeyValue{dbSystem(d)}
+	if cfg.DB.Name != "" {
+		attrs = append(attrs, semconv.DBNamespace(cfg.DB.Name))
+	}
+	DB, err = otelsql.Open(d.DriverName(), dsn,
+		otelsql.WithAttributes(attrs...),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			// Only statements issued under a traced request; startup,
+			// migrations and pool housekeeping would be orphan root spans.
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
 	if err != nil {
-		log.Fatalf("Failed to open database connection: %v", err)
+		return fmt.Errorf("failed to open database connection: %w", err)
 	}
 
+	DB.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime.Duration())
+
 	if err := DB.Ping(); err != nil {
-		log.Fatalf("Database connection failed: %v", err)
+		DB.Close()
+		return fmt.Errorf("database connection failed: %w", err)
 	}
 
-	log.Println("Database connection established successfully")
+	slog.Info("Database connection established successfully", "driver", string(d))
+	return nil
+}
+
+func dbSystem(d dialect.Dialect) attribute.KeyValue {
+	switch d {
+	case dialect.Postgres:
+		return semconv.DBSystemPostgreSQL
+	case dialect.SQLite:
+		return semconv.DBSystemSqlite
+	}
+	return semconv.DBSystemMySQL
+}
+
+func defaultDSN(d dialect.Dialect, db DBConfig) string {
+	port := db.Port
+	switch d {
+	case dialect.Postgres:
+		if port == "" {
+			port = "5432"
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(db.User, db.Password),
+			Host:     db.Host + ":" + port,
+			Path:     db.Name,
+			RawQuery: "sslmode=disable",
+		}
+		return u.String()
+	case dialect.SQLite:
+		return db.Name + ".db"
+	}
+	if port == "" {
+		port = "3306"
+	}
+	// Timestamps are kept in UTC both ways; the date expressions of
+	// dialect.LocalDate rely on it.
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=UTC&time_zone=%s",
+		db.User, db.Password, db.Host, port, db.Name, url.QueryEscape("'+00:00'"))
+}
+
+// sqlitePragmas enables foreign keys, waits on locked databases instead of
+// failing, stores times in a sortable format and starts write transactions
+// immediately so concurrent requests queue up rather than deadlock.
+func sqlitePragmas(dsn string) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_time_format", "sqlite")
+	params.Set("_txlock", "immediate")
+
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + params.Encode()
+	}
+	return dsn + "?" + params.Encode()
 }
@@ -1,19 +1,26 @@
 package controller
 
 import (
-	"database/sql"
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
 	"time"
 
-	"manajemen-karyawan-api/config"
-	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/service/attendance"
 	"manajemen-karyawan-api/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+type AttendanceController struct {
+	attendance *attendance.Service
+}
+
+func NewAttendanceController(svc *attendance.Service) *AttendanceController {
+	return &AttendanceController{attendance: svc}
+}
+
 type ClockRequest struct {
 	Type        string `json:"type" binding:"required"`
 	Description string `json:"description" binding:"required"`
@@ -25,139 +32,68 @@ type ClockRequest struct {
 // @Tags Attendance
 // @Produce json
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/attendance [POST]
-func ClockHandler(c *gin.Context) {
+// @Router /api/v2/me/attendance [post]
+func (ctl *AttendanceController) ClockHandler(c *gin.Context) {
 	employeeID := c.GetString("employee_id")
 
 	var req ClockRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type and description required"})
+	if err := utils.BindJSONStrict(c, &req); err != nil {
 		return
 	}
 
-	now := time.Now()
-
-	var attendanceID string
-	var err error
-
-	if req.Type == "clock_in" {
-		// Check if already clocked in today
-		err = config.DB.QueryRow(`
-			SELECT id FROM attendance
-			WHERE employee_id = ? AND DATE(clock_in) = CURDATE() AND deleted_at IS NULL
-		`, employeeID).Scan(&attendanceID)
-
-		if err != sql.ErrNoRows {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "already clocked in today"})
-			return
-		}
-
-		attendanceID = utils.GenerateID()
-
-		tx, err := config.DB.Begin()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction error"})
-			return
-		}
-
-		_, err = tx.Exec(`
-			INSERT INTO attendance (id, employee_id, clock_in, created_at, created_by)
-			VALUES (?, ?, ?, ?, ?)
-		`, attendanceID, employeeID, now, now, employeeID)
-		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clock in"})
-			return
-		}
-
-		_, err = tx.Exec(`
-			INSERT INTO attendance_history (id, employee_id, attendance_id, date_attendance, attendance_type, description, created_at, created_by)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`, utils.GenerateID(), employeeID, attendanceID, now, 1, req.Description, now, employeeID)
-		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save history"})
-			return
+	// Rule violations come back from the service with their own codes
+	err := ctl.attendance.Clock(c.Request.Context(), employeeID, req.Type, req.Description, time.Now())
+	if err != nil {
+		if req.Type == attendance.ClockIn {
+			c.Error(apperr.Internal(err, "failed to clock in"))
+		} else {
+			c.Error(apperr.Internal(err, "failed to clock out"))
 		}
-
-		tx.Commit()
-		c.JSON(http.StatusOK, gin.H{"message": "clock-in successful"})
 		return
 	}
 
-	if req.Type == "clock_out" {
-		// Get today's attendance record
-		err = config.DB.QueryRow(`
-			SELECT id FROM attendance
-			WHERE employee_id = ? AND DATE(clock_in) = CURDATE() AND deleted_at IS NULL
-		`, employeeID).Scan(&attendanceID)
-
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no clock-in record found"})
-			return
-		}
-
-		tx, err := config.DB.Begin()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction error"})
-			return
-		}
-
-		_, err = tx.Exec(`
-			UPDATE attendance SET clock_out = ?, updated_at = ?, updated_by = ?
-			WHERE id = ?
-		`, now, now, employeeID, attendanceID)
-		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clock out"})
-			return
-		}
-
-		_, err = tx.Exec(`
-			INSERT INTO attendance_history (id, employee_id, attendance_id, date_attendance, attendance_type, description, created_at, created_by)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`, utils.GenerateID(), employeeID, attendanceID, now, 2, req.Description, now, employeeID)
-		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save history"})
-			return
-		}
-
-		tx.Commit()
+	if req.Type == attendance.ClockIn {
+		c.JSON(http.StatusOK, gin.H{"message": "clock-in successful"})
+	} else {
 		c.JSON(http.StatusOK, gin.H{"message": "clock-out successful"})
-		return
 	}
-
-	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type"})
 }
 
-var allowedAttendanceFields = map[string]string{
-	"dateAttendance":      "h.date_attendance",
-	"employeeName":        "e.name",
-	"departementID":       "e.departement_id",
-	"attendanceType ":     "h.attendance_type ",
-	"description":         "h.description",
-	"date_attendance.gte": "date_attendance.gte",
+// listAttendanceLogs serves a paginated log listing, limited to employeeID
+// unless it is empty.
+func (ctl *AttendanceController) listAttendanceLogs(c *gin.Context, params utils.QueryParams, employeeID string) {
+	logs, page, err := ctl.attendance.Logs(c.Request.Context(), params, employeeID)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch attendance logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": logs,
+		"meta": page.Meta(params),
+	})
 }
 
 // GetAttendanceLogs godoc
 // @Summary List log absensi karyawan yang login
-// @Description Menampilkan log absensi milik karyawan yang sedang login, berdasarkan tanggal dan departemen. Autentikasi via JWT cookie.
+// @Description Menampilkan log absensi milik karyawan yang sedang login, bisa difilter misalnya per tanggal (dateAttendance, hari kalender di zona waktu perusahaan) dan departemen. Autentikasi via JWT cookie.
 // @Tags Attendance
+// @Accept json
 // @Produce json
-// @Param date query string false "Tanggal (YYYY-MM-DD)"
-// @Param departement_id query string false "ID Departemen"
+// @Param payload body utils.QueryParams false "page, per_page, sort_by, filter, cursor dan with_total (lihat README)"
 // @Success 200 {object} model.AttendanceItem
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/attendance/logs [POST]
-func GetAttendanceLogs(c *gin.Context) {
+func (ctl *AttendanceController) GetAttendanceLogs(c *gin.Context) {
 	employeeID, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
@@ -165,365 +101,119 @@ func GetAttendanceLogs(c *gin.Context) {
 	if err := utils.BindJSONStrict(c, &params); err != nil {
 		return
 	}
-
-	sortSQL := utils.BuildSortSQL(params.SortBy, allowedAttendanceFields)
-	pagination := utils.BuildPagination(params.Page, params.PerPage)
-	filterSQL, filterArgs := utils.BuildFilterSQL(params.Filter, allowedAttendanceFields)
-
-	query := fmt.Sprintf(`
-		SELECT 
-			a.id,
-			a.employee_id,
-			e.name AS employee_name,
-			d.departement_name,
-			a.clock_in,
-			a.clock_out,
-			d.max_clock_in_time,
-			d.max_clock_out_time,
-			h.date_attendance,
-			h.attendance_type,
-			h.description
-		FROM attendance a
-		JOIN employee e ON a.employee_id = e.employee_id
-		JOIN departement d ON e.departement_id = d.id
-		JOIN attendance_history h ON h.attendance_id = a.id
-		WHERE a.deleted_at IS NULL AND a.employee_id = ?
-		%s
-		%s
-	`, filterSQL, sortSQL)
-
-	args := append([]interface{}{employeeID}, filterArgs...)
-	if pagination.Use {
-		query += " LIMIT ? OFFSET ?"
-		args = append(args, pagination.Limit, pagination.Offset)
-	}
-
-	rows, err := config.DB.Query(query, args...)
-	if err != nil {
-		log.Println("Attendance query error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch attendance logs"})
-		return
-	}
-	defer rows.Close()
-
-	loc, _ := time.LoadLocation("Asia/Singapore")
-	var logs []model.AttendanceItem
-
-	for rows.Next() {
-		var (
-			id              string
-			employeeID      string
-			employeeName    string
-			departementName string
-			clockIn         time.Time
-			clockOut        time.Time
-			maxInRaw        string
-			maxOutRaw       string
-			dateAttendance  time.Time
-			attendanceType  int
-			description     string
-		)
-
-		err := rows.Scan(
-			&id,
-			&employeeID,
-			&employeeName,
-			&departementName,
-			&clockIn,
-			&clockOut,
-			&maxInRaw,
-			&maxOutRaw,
-			&dateAttendance,
-			&attendanceType,
-			&description,
-		)
-		if err != nil {
-			log.Println("Attendance scan error:", err)
-			continue
-		}
-
-		var item model.AttendanceItem
-		item.ID = id
-		item.EmployeeID = employeeID
-		item.EmployeeName = employeeName
-		item.DepartementName = departementName
-		item.DateAttendance = dateAttendance
-		item.Desc = description
-
-		if attendanceType == 1 {
-			item.Clock = clockIn
-			item.MaxClock = maxInRaw
-			item.AttendanceType = "in"
-
-			isLate, err := utils.IsLate(clockIn, maxInRaw, loc)
-			if err != nil {
-				item.Status = "Unknown"
-			} else if isLate {
-				item.Status = "Terlambat"
-			} else {
-				item.Status = "Tepat"
-			}
-		} else if attendanceType == 2 {
-			item.Clock = clockOut
-			item.MaxClock = maxOutRaw
-			item.AttendanceType = "out"
-
-			isEarly, err := utils.IsEarly(clockOut, maxOutRaw, loc)
-			if err != nil {
-				item.Status = "Unknown"
-			} else if isEarly {
-				item.Status = "Pulang Cepat"
-			} else {
-				item.Status = "Tepat"
-			}
-		}
-
-		logs = append(logs, item)
-	}
-
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) FROM attendance a
-		JOIN employee e ON a.employee_id = e.employee_id
-		JOIN departement d ON e.departement_id = d.id
-		JOIN attendance_history h ON h.attendance_id = a.id
-		WHERE a.deleted_at IS NULL AND a.employee_id = ?
-		%s
-	`, filterSQL)
-
-	var total int
-	countArgs := append([]interface{}{employeeID}, filterArgs...)
-	err = config.DB.QueryRow(countQuery, countArgs...).Scan(&total)
-	if err != nil {
-		log.Println("Attendance count error:", err)
-		total = 0
-	}
-
-	meta := utils.BuildMeta(utils.MetaParams{
-		Page:    params.Page,
-		PerPage: params.PerPage,
-		Total:   total,
-		SortBy:  params.SortBy,
-	})
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": logs,
-		"meta": meta,
-	})
+	params.LenientSort = true
+	id, _ := employeeID.(string)
+	ctl.listAttendanceLogs(c, params, id)
 }
 
 // GetAllAttendanceLogs godoc
 // @Summary List semua log absensi karyawan
 // @Description Menampilkan seluruh data absensi karyawan, bisa difilter berdasarkan tanggal dan departemen. Hanya bisa diakses oleh user dengan role tertentu.
 // @Tags Attendance
+// @Accept json
 // @Produce json
-// @Param date query string false "Tanggal (YYYY-MM-DD)"
-// @Param departement_id query string false "ID Departemen"
+// @Param payload body utils.QueryParams false "page, per_page, sort_by, filter, cursor dan with_total (lihat README)"
 // @Success 200 {array} model.AttendanceItem
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/attendance/GetData [POST]
-func GetAllAttendanceLogs(c *gin.Context) {
+func (ctl *AttendanceController) GetAllAttendanceLogs(c *gin.Context) {
 	var params utils.QueryParams
 	if err := utils.BindJSONStrict(c, &params); err != nil {
 		return
 	}
+	params.LenientSort = true
+	ctl.listAttendanceLogs(c, params, "")
+}
 
-	sortSQL := utils.BuildSortSQL(params.SortBy, allowedAttendanceFields)
-	pagination := utils.BuildPagination(params.Page, params.PerPage)
-	filterSQL, filterArgs := utils.BuildFilterSQL(params.Filter, allowedAttendanceFields)
-
-	query := fmt.Sprintf(`
-		SELECT 
-			a.id,
-			a.employee_id,
-			e.name AS employee_name,
-			d.departement_name,
-			a.clock_in,
-			a.clock_out,
-			d.max_clock_in_time,
-			d.max_clock_out_time,
-			h.date_attendance,
-			h.attendance_type,
-			h.description
-		FROM attendance a
-		JOIN employee e ON a.employee_id = e.employee_id
-		JOIN departement d ON e.departement_id = d.id
-		JOIN attendance_history h ON h.attendance_id = a.id
-		WHERE a.deleted_at IS NULL
-		%s
-		%s
-	`, filterSQL, sortSQL)
-
-	args := filterArgs
-	if pagination.Use {
-		query += " LIMIT ? OFFSET ?"
-		args = append(args, pagination.Limit, pagination.Offset)
-	}
-
-	rows, err := config.DB.Query(query, args...)
-	if err != nil {
-		log.Println("Attendance query error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch attendance logs"})
+// GetTodayAttendance godoc
+// @Summary Ambil data absensi hari ini milik user yang login
+// @Description Menampilkan data absensi karyawan yang sedang login untuk tanggal hari ini. Autentikasi via JWT cookie.
+// @Tags Attendance
+// @Produce json
+// @Success 200 {object} model.AttendanceItem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/attendance/today [get]
+// @Router /api/v2/me/attendance/today [get]
+func (ctl *AttendanceController) GetTodayAttendance(c *gin.Context) {
+	employeeID, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
-	defer rows.Close()
-
-	loc, _ := time.LoadLocation("Asia/Singapore")
-	var logs []model.AttendanceItem
-
-	for rows.Next() {
-		var (
-			id              string
-			employeeID      string
-			employeeName    string
-			departementName string
-			clockIn         time.Time
-			clockOut        time.Time
-			maxInRaw        string
-			maxOutRaw       string
-			dateAttendance  time.Time
-			attendanceType  int
-			description     string
-		)
-
-		err := rows.Scan(
-			&id,
-			&employeeID,
-			&employeeName,
-			&departementName,
-			&clockIn,
-			&clockOut,
-			&maxInRaw,
-			&maxOutRaw,
-			&dateAttendance,
-			&attendanceType,
-			&description,
-		)
-		if err != nil {
-			log.Println("Attendance scan error:", err)
-			continue
-		}
-
-		var item model.AttendanceItem
-		item.ID = id
-		item.EmployeeID = employeeID
-		item.EmployeeName = employeeName
-		item.DepartementName = departementName
-		item.DateAttendance = dateAttendance
-		item.Desc = description
-
-		if attendanceType == 1 {
-			item.Clock = clockIn
-			item.MaxClock = maxInRaw
-			item.AttendanceType = "in"
-
-			isLate, err := utils.IsLate(clockIn, maxInRaw, loc)
-			if err != nil {
-				item.Status = "Unknown"
-			} else if isLate {
-				item.Status = "Terlambat"
-			} else {
-				item.Status = "Tepat"
-			}
-		} else if attendanceType == 2 {
-			item.Clock = clockOut
-			item.MaxClock = maxOutRaw
-			item.AttendanceType = "out"
 
-			isEarly, err := utils.IsEarly(clockOut, maxOutRaw, loc)
-			if err != nil {
-				item.Status = "Unknown"
-			} else if isEarly {
-				item.Status = "Pulang Cepat"
-			} else {
-				item.Status = "Tepat"
-			}
-		}
-
-		logs = append(logs, item)
-	}
-
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) FROM attendance a
-		JOIN employee e ON a.employee_id = e.employee_id
-		JOIN departement d ON e.departement_id = d.id
-		JOIN attendance_history h ON h.attendance_id = a.id
-		WHERE a.deleted_at IS NULL
-		%s
-	`, filterSQL)
-
-	var total int
-	err = config.DB.QueryRow(countQuery, filterArgs...).Scan(&total)
-	if err != nil {
-		log.Println("Attendance count error:", err)
-		total = 0
+	id, _ := employeeID.(string)
+	today, err := ctl.attendance.Today(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusOK, gin.H{})
+		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch attendance"))
+		return
 	}
 
-	meta := utils.BuildMeta(utils.MetaParams{
-		Page:    params.Page,
-		PerPage: params.PerPage,
-		Total:   total,
-		SortBy:  params.SortBy,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
-		"data": logs,
-		"meta": meta,
+		"clock_in":  today.ClockIn,
+		"clock_out": today.ClockOut,
+		"location":  nil,
 	})
 }
 
-var result struct {
-	ClockIn  *time.Time `json:"clock_in"`
-	ClockOut *time.Time `json:"clock_out"`
-	Location *string    `json:"location"`
+type AttendanceSummaryRequest struct {
+	DateFrom      string `json:"dateFrom" binding:"required"`
+	DateTo        string `json:"dateTo" binding:"required"`
+	DepartementID string `json:"departementID"`
 }
 
-// GetTodayAttendance godoc
-// @Summary Ambil data absensi hari ini milik user yang login
-// @Description Menampilkan data absensi karyawan yang sedang login untuk tanggal hari ini. Autentikasi via JWT cookie.
+// GetAttendanceSummary godoc
+// @Summary Rekap absensi per departemen
+// @Description Menghitung jumlah clock-in, terlambat, clock-out dan pulang cepat per departemen dalam rentang tanggal (YYYY-MM-DD). Angka setiap node sudah termasuk semua sub-departemen di bawahnya. Autentikasi via JWT cookie.
 // @Tags Attendance
+// @Accept json
 // @Produce json
-// @Success 200 {object} model.AttendanceItem
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /api/attendance/today [get]
-func GetTodayAttendance(c *gin.Context) {
-	employeeID, exists := c.Get("employee_id")
+// @Param payload body AttendanceSummaryRequest true "Rentang tanggal"
+// @Success 200 {array} model.DepartementAttendanceSummary
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/attendance/summary [POST]
+func (ctl *AttendanceController) GetAttendanceSummary(c *gin.Context) {
+	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
-	// Get today's date range
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	query := `
-		SELECT 
-			clock_in, clock_out
-		FROM attendance
-		WHERE employee_id = ?
-		AND created_at >= ? AND created_at < ?
-		LIMIT 1
-	`
+	var req AttendanceSummaryRequest
+	if err := utils.BindJSONStrict(c, &req); err != nil {
+		return
+	}
+	ctl.attendanceSummary(c, req)
+}
 
-	err := config.DB.QueryRow(query, employeeID, startOfDay, endOfDay).Scan(
-		&result.ClockIn,
-		&result.ClockOut,
-	)
+func (ctl *AttendanceController) attendanceSummary(c *gin.Context, req AttendanceSummaryRequest) {
+	dateFrom, err := ctl.attendance.ParseDate(req.DateFrom)
+	if err != nil {
+		c.Error(apperr.Invalid("dateFrom", "date", "dateFrom must be YYYY-MM-DD"))
+		return
+	}
+	dateTo, err := ctl.attendance.ParseDate(req.DateTo)
+	if err != nil {
+		c.Error(apperr.Invalid("dateTo", "date", "dateTo must be YYYY-MM-DD"))
+		return
+	}
 
+	// An unknown departementID comes back as a not_found error
+	result, err := ctl.attendance.Summary(c.Request.Context(), dateFrom, dateTo, req.DepartementID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusOK, gin.H{})
-			return
-		}
-		log.Println("Attendance query error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch attendance"})
+		c.Error(apperr.Internal(err, "failed to fetch attendance summary"))
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, gin.H{"data": result})
 }
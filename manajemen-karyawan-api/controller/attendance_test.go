@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/repository/memstore"
+	"manajemen-karyawan-api/service/attendance"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAttendanceRouter(t *testing.T) (*gin.Engine, *memstore.Store) {
+	store := memstore.New(nil)
+	seedDepartement(t, store, "dep-it", "IT", "08:00:00", "17:00:00")
+	seedEmployee(t, store, testActor, "dep-it")
+
+	ctl := NewAttendanceController(attendance.NewService(store, time.UTC))
+	r := newTestRouter()
+	r.POST("/api/attendance", ctl.ClockHandler)
+	r.GET("/api/attendance/today", ctl.GetTodayAttendance)
+	r.POST("/api/attendance/logs", ctl.GetAttendanceLogs)
+	return r, store
+}
+
+func TestClockHandler(t *testing.T) {
+	r, _ := newAttendanceRouter(t)
+	clock := func(clockType string) testResponse {
+		return do(t, r, http.MethodPost, "/api/attendance", ClockRequest{Type: clockType, Description: "WFO"})
+	}
+
+	var today map[string]interface{}
+	do(t, r, http.MethodGet, "/api/attendance/today", nil).expect(http.StatusOK).decode(&today)
+	if len(today) != 0 {
+		t.Errorf("today before clocking in = %v", today)
+	}
+
+	clock(attendance.ClockOut).expectProblem(http.StatusConflict, apperr.CodeNotClockedIn)
+	clock(attendance.ClockIn).expect(http.StatusOK)
+	clock(attendance.ClockIn).expectProblem(http.StatusConflict, apperr.CodeAlreadyClockedIn)
+
+	do(t, r, http.MethodGet, "/api/attendance/today", nil).expect(http.StatusOK).decode(&today)
+	if today["clock_in"] == nil || today["clock_out"] != nil {
+		t.Errorf("today after clocking in = %v", today)
+	}
+
+	clock(attendance.ClockOut).expect(http.StatusOK)
+	do(t, r, http.MethodGet, "/api/attendance/today", nil).expect(http.StatusOK).decode(&today)
+	if today["clock_out"] == nil {
+		t.Errorf("today after clocking out = %v", today)
+	}
+
+	var logs struct {
+		Data []struct {
+			AttendanceType string
+			Desc           string
+		}
+	}
+	do(t, r, http.MethodPost, "/api/attendance/logs", map[string]interface{}{}).expect(http.StatusOK).decode(&logs)
+	if len(logs.Data) != 2 || logs.Data[0].AttendanceType != "out" || logs.Data[1].AttendanceType != "in" {
+		t.Errorf("logs = %+v", logs.Data)
+	}
+}
+
+func TestClockHandlerBadRequest(t *testing.T) {
+	r, store := newAttendanceRouter(t)
+
+	tests := []struct {
+		name string
+		body interface{}
+		code apperr.Code
+	}{
+		{"unknown type", ClockRequest{Type: "lunch", Description: "WFO"}, apperr.CodeValidationFailed},
+		{"missing description", map[string]string{"type": attendance.ClockIn}, apperr.CodeValidationFailed},
+		{"wrong type", map[string]interface{}{"type": 1, "description": "WFO"}, apperr.CodeValidationFailed},
+		{"empty body", nil, apperr.CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			do(t, r, http.MethodPost, "/api/attendance", tt.body).expectProblem(http.StatusBadRequest, tt.code)
+		})
+	}
+
+	if logs, _ := store.Attendance().LogsBetween(t.Context(), time.Time{}, time.Now().Add(time.Hour)); len(logs) != 0 {
+		t.Errorf("rejected requests wrote %d entries", len(logs))
+	}
+}
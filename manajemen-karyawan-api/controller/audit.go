@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	store     repository.Store
+	retention config.RetentionConfig
+	// Business timezone the from/to dates of the audit filter are in
+	loc *time.Location
+}
+
+func NewAdminController(store repository.Store, retention config.RetentionConfig, loc *time.Location) *AdminController {
+	return &AdminController{store: store, retention: retention, loc: loc}
+}
+
+// recordAudit writes one entry of the change trail, attributed to the user
+// and request of c. Pass the transactional store of the change it describes.
+func recordAudit(store repository.Store, c *gin.Context, entity string, entityID string, action string, changes map[string]model.AuditChange) error {
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	entry := model.AuditLog{
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Actor:     c.GetString("employee_id"),
+		Changes:   payload,
+		CreatedAt: time.Now(),
+	}
+	if id := c.GetString("request_id"); id != "" {
+		entry.RequestID = &id
+	}
+	if ip := c.ClientIP(); ip != "" {
+		entry.ClientIP = &ip
+	}
+
+	return store.Audit().Record(c.Request.Context(), entry)
+}
+
+// recordUpdate records the changes returned by a repository Update, if any.
+func recordUpdate(store repository.Store, c *gin.Context, entity string, entityID string, changes map[string]model.AuditChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return recordAudit(store, c, entity, entityID, model.AuditActionUpdate, changes)
+}
+
+// GetAuditLogs godoc
+// @Summary Riwayat perubahan data (audit trail)
+// @Description Menampilkan riwayat perubahan per field (nilai lama dan baru) untuk semua entitas. Bisa difilter berdasarkan entity, entity_id, actor dan rentang tanggal (YYYY-MM-DD, zona waktu perusahaan). Hanya untuk admin.
+// @Tags Admin
+// @Produce json
+// @Param entity query string false "Nama entitas (employee, departement, ...)"
+// @Param entity_id query string false "ID data"
+// @Param actor query string false "Employee ID pelaku"
+// @Param from query string false "Tanggal awal (YYYY-MM-DD)"
+// @Param to query string false "Tanggal akhir (YYYY-MM-DD)"
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Success 200 {array} model.AuditLog
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/audit [get]
+// @Router /api/v2/audit [get]
+func (ctl *AdminController) GetAuditLogs(c *gin.Context) {
+	filter := repository.AuditFilter{
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+		Actor:    c.Query("actor"),
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, ctl.loc)
+		if err != nil {
+			c.Error(apperr.Invalid("from", "date", "from must be YYYY-MM-DD"))
+			return
+		}
+		filter.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, ctl.loc)
+		if err != nil {
+			c.Error(apperr.Invalid("to", "date", "to must be YYYY-MM-DD"))
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	page, perPage, pagination := queryPagination(c)
+
+	result, total, err := ctl.store.Audit().List(c.Request.Context(), filter, pagination)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch audit logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+		"meta": utils.BuildMeta(utils.MetaParams{Page: page, PerPage: perPage, Total: total}),
+	})
+}
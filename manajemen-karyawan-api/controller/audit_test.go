@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository/memstore"
+)
+
+func TestGetAuditLogsDateRange(t *testing.T) {
+	singapore, err := time.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memstore.New(singapore)
+
+	// 23:30 on the 4th, 00:30 and 23:30 on the 5th and 00:30 on the 6th in
+	// Singapore; read as UTC dates the 5th would hold the last two instead
+	for i, at := range []string{"2024-03-04T15:30:00Z", "2024-03-04T16:30:00Z", "2024-03-05T15:30:00Z", "2024-03-05T16:30:00Z"} {
+		created, _ := time.Parse(time.RFC3339, at)
+		entry := model.AuditLog{Entity: "employee", EntityID: string(rune('a' + i)), Action: model.AuditActionUpdate, CreatedAt: created}
+		if err := store.Audit().Record(context.Background(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctl := NewAdminController(store, config.RetentionConfig{}, singapore)
+	r := newTestRouter()
+	r.GET("/api/audit", ctl.GetAuditLogs)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"from=2024-03-05&to=2024-03-05", "cb"},
+		{"from=2024-03-05", "dcb"},
+		{"to=2024-03-04", "a"},
+		{"from=2024-03-06&to=2024-03-06", "d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var resp struct{ Data []model.AuditLog }
+			do(t, r, http.MethodGet, "/api/audit?"+tt.query, nil).expect(http.StatusOK).decode(&resp)
+			got := ""
+			for _, entry := range resp.Data {
+				got += entry.EntityID
+			}
+			if got != tt.want {
+				t.Errorf("entities = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
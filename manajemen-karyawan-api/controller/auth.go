@@ -1,18 +1,30 @@
 package controller
 
 import (
-	"database/sql"
-	"log"
+	"errors"
 	"net/http"
 
+	"manajemen-karyawan-api/apperr"
 	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/metrics"
 	"manajemen-karyawan-api/middleware"
 	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
+type AuthController struct {
+	store repository.Store
+	auth  config.AuthConfig
+}
+
+func NewAuthController(store repository.Store, auth config.AuthConfig) *AuthController {
+	return &AuthController{store: store, auth: auth}
+}
+
 type LoginRequest struct {
 	EmployeeID string `json:"employeeID" binding:"required"`
 	Password   string `json:"password" binding:"required"`
@@ -26,57 +38,48 @@ type LoginRequest struct {
 // @Produce json
 // @Param request body LoginRequest true "Employee ID dan Password"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/auth/login [post]
-func Login(c *gin.Context) {
+// @Router /api/v2/auth/login [post]
+func (ctl *AuthController) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id and password are required"})
+	if err := utils.BindJSONStrict(c, &req); err != nil {
+		metrics.FailedLogins.WithLabelValues(metrics.LoginInvalidRequest).Inc()
 		return
 	}
 
-	var emp model.Employee
-	query := `
-		SELECT id, employee_id, departement_id, name, address, password,
-		       created_at, created_by, updated_at, updated_by, deleted_at, deleted_by
-		FROM employee
-		WHERE employee_id = ? AND deleted_at IS NULL
-	`
-	err := config.DB.QueryRow(query, req.EmployeeID).Scan(
-		&emp.ID, &emp.EmployeeID, &emp.DepartementID, &emp.Name, &emp.Address, &emp.Password,
-		&emp.CreatedAt, &emp.CreatedBy, &emp.UpdatedAt, &emp.UpdatedBy,
-		&emp.DeletedAt, &emp.DeletedBy,
-	)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid employee_id"})
+	emp, err := ctl.store.Employees().GetByEmployeeID(c.Request.Context(), req.EmployeeID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && emp.Status != model.EmployeeStatusActive) {
+		metrics.FailedLogins.WithLabelValues(metrics.LoginUnknownEmployee).Inc()
+		c.Error(apperr.New(apperr.CodeInvalidCredentials, "invalid employee_id"))
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		c.Error(apperr.Internal(err, "failed to log in"))
 		return
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(emp.Password), []byte(req.Password)) != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		metrics.FailedLogins.WithLabelValues(metrics.LoginWrongPassword).Inc()
+		c.Error(apperr.New(apperr.CodeInvalidCredentials, "invalid password"))
 		return
 	}
 
-	token, err := middleware.GenerateToken(emp.ID, emp.EmployeeID, []byte(config.JWTSecret))
+	token, err := middleware.GenerateToken(emp.ID, emp.EmployeeID, emp.Role, []byte(ctl.auth.JWTSecret), ctl.auth.TokenTTL.Duration())
 	if err != nil {
-		log.Println("JWT generation error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		c.Error(apperr.Internal(err, "failed to generate token"))
 		return
 	}
 
 	c.SetCookie(
 		"access_token",
 		token,
-		3600,
+		int(ctl.auth.CookieTTL.Duration().Seconds()),
 		"/",
-		config.CookieDomain,
-		false, // true if using HTTPS
-		true,  // HttpOnly
+		ctl.auth.CookieDomain,
+		ctl.auth.CookieSecure, // HTTPS only
+		true,                  // HttpOnly
 	)
 
 	c.JSON(http.StatusOK, gin.H{"message": "login successful"})
@@ -88,22 +91,23 @@ func Login(c *gin.Context) {
 // @Tags Auth
 // @Produce json
 // @Success 200 {object} model.Employee
-// @Failure 401 {object} map[string]string
+// @Failure 401 {object} apperr.Problem
 // @Router /api/auth/me [get]
-func GetMe(c *gin.Context) {
+// @Router /api/v2/me [get]
+func (ctl *AuthController) GetMe(c *gin.Context) {
 	empID, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
-	var emp model.Employee
-	if err := config.DB.QueryRow(`
-		SELECT id, employee_id, name
-		FROM employee
-		WHERE employee_id = ? AND deleted_at IS NULL
-	`, empID).Scan(&emp.ID, &emp.EmployeeID, &emp.Name); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	id, _ := empID.(string)
+	emp, err := ctl.store.Employees().GetByEmployeeID(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("employee not found"))
+		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch employee"))
 		return
 	}
 
@@ -111,6 +115,7 @@ func GetMe(c *gin.Context) {
 		"id":         emp.ID,
 		"employeeID": emp.EmployeeID,
 		"name":       emp.Name,
+		"role":       emp.Role,
 	})
 }
 
@@ -120,16 +125,17 @@ func GetMe(c *gin.Context) {
 // @Tags Auth
 // @Produce json
 // @Success 200 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 401 {object} apperr.Problem
 // @Router /api/auth/logout [post]
-func Logout(c *gin.Context) {
+// @Router /api/v2/auth/logout [post]
+func (ctl *AuthController) Logout(c *gin.Context) {
 	c.SetCookie(
 		"access_token",
 		"",
 		-1,
 		"/",
-		config.CookieDomain,
-		false,
+		ctl.auth.CookieDomain,
+		ctl.auth.CookieSecure,
 		true,
 	)
 
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/middleware"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository/memstore"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testActor is the employee code every test request is authenticated as.
+const testActor = "ADM001"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter returns an engine with the error handling of the real one
+// and the JWT middleware replaced by a fixed login as testActor.
+func newTestRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ErrorMiddleware())
+	r.Use(func(c *gin.Context) {
+		c.Set("employee_id", testActor)
+		c.Set("role", model.EmployeeRoleAdmin)
+		c.Next()
+	})
+	return r
+}
+
+type testResponse struct {
+	*httptest.ResponseRecorder
+	t *testing.T
+}
+
+// do sends a request with body encoded as JSON unless it is nil.
+func do(t *testing.T, r http.Handler, method, target string, body interface{}, header ...string) testResponse {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return testResponse{w, t}
+}
+
+// expect fails the test unless the response has the given status.
+func (w testResponse) expect(status int) testResponse {
+	w.t.Helper()
+	if w.Code != status {
+		w.t.Fatalf("status = %d, want %d; body %s", w.Code, status, w.Body.String())
+	}
+	return w
+}
+
+// decode reads the JSON body into v.
+func (w testResponse) decode(v interface{}) {
+	w.t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		w.t.Fatalf("decoding %s: %v", w.Body.String(), err)
+	}
+}
+
+// expectProblem fails the test unless the response is a problem with the
+// given status and code.
+func (w testResponse) expectProblem(status int, code apperr.Code) apperr.Problem {
+	w.t.Helper()
+	w.expect(status)
+	var p apperr.Problem
+	w.decode(&p)
+	if p.Code != code {
+		w.t.Fatalf("code = %q, want %q; body %s", p.Code, code, w.Body.String())
+	}
+	return p
+}
+
+func strPtr(s string) *string { return &s }
+
+// seedDepartement creates an active departement with its own clock rules.
+func seedDepartement(t *testing.T, store *memstore.Store, id, name, maxIn, maxOut string) {
+	t.Helper()
+	node := model.DepartementNode{ID: id, DepartementName: name, MaxClockInTime: &maxIn, MaxClockOutTime: &maxOut}
+	if err := store.Departements().Create(context.Background(), node, testActor, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seedEmployee creates an active employee and returns its row ID.
+func seedEmployee(t *testing.T, store *memstore.Store, code, departementID string) string {
+	t.Helper()
+	e := model.Employee{
+		ID:            "id-" + code,
+		EmployeeID:    code,
+		DepartementID: departementID,
+		Name:          "Employee " + code,
+		Status:        model.EmployeeStatusActive,
+	}
+	e.CreatedAt = time.Now()
+	e.CreatedBy = testActor
+	if err := store.Employees().Create(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	return e.ID
+}
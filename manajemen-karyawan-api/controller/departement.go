@@ -1,39 +1,49 @@
 package controller
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
-	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/apperr"
 	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/service/search"
 	"manajemen-karyawan-api/utils"
+	"manajemen-karyawan-api/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
-var allowedFields = map[string]string{
-	"id":              "id",
-	"departementName": "departement_name",
-	"maxClockInTime":  "max_Clock_In_Time",
-	"maxClockOutTime": "max_Clock_Out_Time",
-	"createdAt":       "createdAt",
+type DepartementController struct {
+	store repository.Store
+	// Indexes employees by departement name
+	search *search.Index
+	// Business timezone; reassignments are dated in it
+	loc *time.Location
+}
+
+func NewDepartementController(store repository.Store, index *search.Index, loc *time.Location) *DepartementController {
+	return &DepartementController{store: store, search: index, loc: loc}
 }
 
 // GetAllDepartements godoc
 // @Summary Ambil semua departemen
 // @Description Mengembalikan list semua departemen aktif. Requires valid JWT cookie named "token"
 // @Tags Departement
+// @Accept json
 // @Produce json
+// @Param payload body utils.QueryParams false "page, per_page, sort_by, filter, cursor dan with_total (lihat README)"
 // @Success 200 {array} model.Departement
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/departement/GetData [POST]
-func GetAllDepartements(c *gin.Context) {
+func (ctl *DepartementController) GetAllDepartements(c *gin.Context) {
 	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
@@ -41,85 +51,21 @@ func GetAllDepartements(c *gin.Context) {
 	if err := utils.BindJSONStrict(c, &params); err != nil {
 		return
 	}
+	params.LenientSort = true
+	ctl.listDepartements(c, params)
+}
 
-	sortSQL := utils.BuildSortSQL(params.SortBy, allowedFields)
-	pagination := utils.BuildPagination(params.Page, params.PerPage)
-
-	filterSQL, filterArgs := utils.BuildFilterSQL(params.Filter, allowedFields)
-
-	// Build query
-	query := fmt.Sprintf(`
-		SELECT id, departement_name, max_clock_in_time, max_clock_out_time,
-		       created_at, created_by, updated_at, updated_by, deleted_at, deleted_by
-		FROM departement
-		WHERE deleted_at IS NULL
-		%s
-		%s
-	`, filterSQL, sortSQL)
-
-	var args []interface{}
-	args = append(args, filterArgs...)
-
-	if pagination.Use {
-		query += " LIMIT ? OFFSET ?"
-		args = append(args, pagination.Limit, pagination.Offset)
-	}
-
-	rows, err := config.DB.Query(query, args...)
+func (ctl *DepartementController) listDepartements(c *gin.Context, params utils.QueryParams) {
+	result, page, err := ctl.store.Departements().List(c.Request.Context(), params)
 	if err != nil {
-		log.Println("Departement query error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch departements"})
+		c.Error(apperr.Internal(err, "failed to fetch departements"))
 		return
 	}
-	defer rows.Close()
-
-	var result []model.Departement
-
-	for rows.Next() {
-		var d model.Departement
-		var clockInRaw, clockOutRaw string
-		err := rows.Scan(
-			&d.ID, &d.DepartementName,
-			&clockInRaw, &clockOutRaw,
-			&d.CreatedAt, &d.CreatedBy, &d.UpdatedAt, &d.UpdatedBy,
-			&d.DeletedAt, &d.DeletedBy,
-		)
-		if err != nil {
-			log.Println("Departement scan error:", err)
-			continue
-		}
-
-		d.MaxClockInTime, _ = time.Parse("15:04:05", clockInRaw)
-		d.MaxClockOutTime, _ = time.Parse("15:04:05", clockOutRaw)
-
-		result = append(result, d)
-	}
-
-	// Count total with filter
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) FROM departement
-		WHERE deleted_at IS NULL
-		%s
-	`, filterSQL)
-
-	var total int
-	err = config.DB.QueryRow(countQuery, filterArgs...).Scan(&total)
-	if err != nil {
-		log.Println("Departement count error:", err)
-		total = 0
-	}
 
 	// Response
-	meta := utils.BuildMeta(utils.MetaParams{
-		Page:    params.Page,
-		PerPage: params.PerPage,
-		Total:   total,
-		SortBy:  params.SortBy,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
 		"data": result,
-		"meta": meta,
+		"meta": page.Meta(params),
 	})
 }
 
@@ -130,121 +76,287 @@ func GetAllDepartements(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Departement ID"
 // @Success 200 {object} model.Departement
-// @Failure 401 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Versi data, dikirim kembali lewat If-Match saat update/hapus"
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/departement/{id} [get]
-func GetDepartementByID(c *gin.Context) {
+// @Router /api/v2/departements/{id} [get]
+func (ctl *DepartementController) GetDepartementByID(c *gin.Context) {
 	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
 	id := c.Param("id")
 
-	var d model.Departement
-	err := config.DB.QueryRow(`
-		SELECT id, departement_name, max_clock_in_time, max_clock_out_time,
-		       created_at, created_by, updated_at, updated_by, deleted_at, deleted_by
-		FROM departement
-		WHERE id = ? AND deleted_at IS NULL
-	`, id).Scan(
-		&d.ID, &d.DepartementName, &d.MaxClockInTime, &d.MaxClockOutTime,
-	)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "departement not found"})
+	d, err := ctl.store.Departements().GetByID(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("departement not found"))
 		return
 	} else if err != nil {
-		log.Println("Departement detail error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		c.Error(apperr.Internal(err, "failed to fetch departement"))
 		return
 	}
 
+	utils.SetETag(c, d.Version)
 	c.JSON(http.StatusOK, d)
 }
 
+// DepartementPayload is the body of both create and update. "" clears a
+// parent, head, clock rule or timezone, the last two then being inherited.
 type DepartementPayload struct {
-	Name            *string `json:"departementName,omitempty"`
-	MaxClockInTime  *string `json:"maxClockInTime,omitempty"`
-	MaxClockOutTime *string `json:"maxClockOutTime,omitempty"`
+	Name            *string `json:"departementName,omitempty" validate:"required_on_create,omitempty,notblank,max=255"`
+	ParentID        *string `json:"parentID,omitempty" validate:"omitempty,max=50"`
+	HeadEmployeeID  *string `json:"headEmployeeID,omitempty" validate:"omitempty,max=50"`
+	MaxClockInTime  *string `json:"maxClockInTime,omitempty" validate:"omitempty,clock|len=0"`
+	MaxClockOutTime *string `json:"maxClockOutTime,omitempty" validate:"omitempty,clock|len=0"`
+	// IANA timezone such as Asia/Jakarta
+	Timezone *string `json:"timezone,omitempty" validate:"omitempty,max=64,timezone|len=0"`
+}
+
+// validateDepartementTree checks parent, head and clock rules of a payload
+// against the current tree, after the payload's own rules passed. id is
+// empty when creating a new departement.
+func (ctl *DepartementController) validateDepartementTree(c *gin.Context, tree model.DepartementTree, id string, req DepartementPayload) error {
+	parentID := ""
+	maxIn, maxOut := "", ""
+	if node, ok := tree[id]; ok {
+		parentID = derefString(node.ParentID)
+		maxIn = derefString(node.MaxClockInTime)
+		maxOut = derefString(node.MaxClockOutTime)
+	}
+	if req.ParentID != nil {
+		parentID = *req.ParentID
+	}
+	if req.MaxClockInTime != nil {
+		maxIn = *req.MaxClockInTime
+	}
+	if req.MaxClockOutTime != nil {
+		maxOut = *req.MaxClockOutTime
+	}
+
+	if parentID != "" {
+		if _, ok := tree[parentID]; !ok {
+			return apperr.Invalid("parentID", "exists", "parent departement not found")
+		}
+		if parentID == id {
+			return apperr.Invalid("parentID", "cycle", model.ErrDepartementCycle.Error())
+		}
+		if id != "" {
+			if err := tree.ValidateParent(id, parentID); err != nil {
+				return apperr.Invalid("parentID", "cycle", err.Error())
+			}
+		}
+	} else if maxIn == "" || maxOut == "" {
+		var fields []apperr.FieldError
+		if maxIn == "" {
+			fields = append(fields, apperr.FieldError{Field: "maxClockInTime", Code: "required", Message: "root departement requires maxClockInTime"})
+		}
+		if maxOut == "" {
+			fields = append(fields, apperr.FieldError{Field: "maxClockOutTime", Code: "required", Message: "root departement requires maxClockOutTime"})
+		}
+		return apperr.Validation(fields...)
+	}
+
+	// Clock-in must come before clock-out, comparing what will apply once
+	// saved: the departement's own times or else the inherited ones.
+	// HH:MM:SS compares correctly as text.
+	effIn, effOut := maxIn, maxOut
+	if parentID != "" {
+		inheritedIn, inheritedOut := tree.ClockRules(parentID)
+		if effIn == "" {
+			effIn = inheritedIn
+		}
+		if effOut == "" {
+			effOut = inheritedOut
+		}
+	}
+	if effIn != "" && effOut != "" && effIn >= effOut {
+		field := "maxClockInTime"
+		if req.MaxClockInTime == nil && req.MaxClockOutTime != nil {
+			field = "maxClockOutTime"
+		}
+		return apperr.Invalid(field, "clock_order", fmt.Sprintf("maxClockInTime (%s) must be before maxClockOutTime (%s)", effIn, effOut))
+	}
+
+	if req.HeadEmployeeID != nil && *req.HeadEmployeeID != "" {
+		existsHead, err := ctl.store.Employees().Exists(c.Request.Context(), *req.HeadEmployeeID)
+		if err != nil {
+			return apperr.Internal(err, "failed to check head employee")
+		}
+		if !existsHead {
+			return apperr.Invalid("headEmployeeID", "exists", "head employee not found")
+		}
+	}
+
+	return nil
+}
+
+// nullIfEmpty maps an empty optional string to SQL NULL, so "" can be used
+// to clear a parent, head, inherited clock rule or timezone.
+func nullIfEmpty(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// checkNameFree reports a conflict when another active departement already
+// has name, ignoring case, since the employee import looks them up by name.
+func checkNameFree(ctx context.Context, store repository.Store, name string, excludeID string) error {
+	taken, err := store.Departements().NameTaken(ctx, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apperr.Conflict(fmt.Sprintf("departement %s already exists", name))
+	}
+	return nil
 }
 
 // CreateDepartement godoc
 // @Summary Tambah departemen baru
-// @Description Menambahkan data departemen ke sistem. Hanya dapat diakses oleh user dengan role admin. Autentikasi via JWT cookie.
+// @Description Menambahkan data departemen ke sistem. Nama departemen harus unik (tidak membedakan huruf besar/kecil). Hanya dapat diakses oleh user dengan role admin. Autentikasi via JWT cookie.
 // @Tags Departement
 // @Accept json
 // @Produce json
 // @Param payload body DepartementPayload true "Data departemen"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/departement [post]
-func CreateDepartement(c *gin.Context) {
-	employeeID, exists := c.Get("employee_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+func (ctl *DepartementController) CreateDepartement(c *gin.Context) {
+	id, ok := ctl.createDepartement(c)
+	if !ok {
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "departement created", "id": id})
+}
+
+// createDepartement creates a departement from the request and returns its
+// ID; on failure the error is already attached to c.
+func (ctl *DepartementController) createDepartement(c *gin.Context) (string, bool) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return "", false
+	}
 
 	var req DepartementPayload
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
-		return
+	if err := utils.BindPayload(c, &req, validation.Create); err != nil {
+		return "", false
 	}
 
-	if req.Name == nil || req.MaxClockInTime == nil || req.MaxClockOutTime == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "all fields are required"})
-		return
+	ctx := c.Request.Context()
+	tree, err := ctl.store.Departements().Tree(ctx)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to create departement"))
+		return "", false
 	}
 
-	id := utils.GenerateID()
-	now := time.Now()
+	if err := ctl.validateDepartementTree(c, tree, "", req); err != nil {
+		c.Error(err)
+		return "", false
+	}
 
-	_, err := config.DB.Exec(`
-		INSERT INTO departement (id, departement_name, max_clock_in_time, max_clock_out_time, created_at, created_by)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, id, req.Name, req.MaxClockInTime, req.MaxClockOutTime, now, employeeID)
+	node := model.DepartementNode{
+		ID:              utils.GenerateID(),
+		ParentID:        nullIfEmpty(req.ParentID),
+		HeadEmployeeID:  nullIfEmpty(req.HeadEmployeeID),
+		DepartementName: *req.Name,
+		MaxClockInTime:  nullIfEmpty(req.MaxClockInTime),
+		MaxClockOutTime: nullIfEmpty(req.MaxClockOutTime),
+		Timezone:        nullIfEmpty(req.Timezone),
+	}
 
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := checkNameFree(ctx, tx, node.DepartementName, ""); err != nil {
+			return err
+		}
+		if err := tx.Departements().Create(ctx, node, c.GetString("employee_id"), time.Now()); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, "departement", node.ID, model.AuditActionCreate, repository.DiffValues(nil, map[string]interface{}{
+			"parent_id":          node.ParentID,
+			"head_employee_id":   node.HeadEmployeeID,
+			"departement_name":   node.DepartementName,
+			"max_clock_in_time":  node.MaxClockInTime,
+			"max_clock_out_time": node.MaxClockOutTime,
+			"timezone":           node.Timezone,
+		}))
+	})
 	if err != nil {
-		log.Println("Create departement error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create departement"})
-		return
+		c.Error(apperr.Internal(err, "failed to create departement"))
+		return "", false
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "departement created", "id": id})
+	return node.ID, true
 }
 
 // UpdateDepartement godoc
 // @Summary Update data departemen
-// @Description Mengubah data departemen berdasarkan ID. Autentikasi via JWT cookie.
+// @Description Mengubah data departemen berdasarkan ID. Nama baru ditolak (409) bila sudah dipakai departemen lain. Jika If-Match diisi dengan ETag dari GET, perubahan ditolak (412) bila data sudah diubah orang lain. Autentikasi via JWT cookie.
 // @Tags Departement
 // @Accept json
 // @Produce json
 // @Param id path string true "ID Departemen"
+// @Param If-Match header string false "ETag versi yang diubah"
 // @Param payload body DepartementPayload true "Data departemen"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "Versi data setelah update"
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/departement/{id} [put]
-func UpdateDepartement(c *gin.Context) {
-	employeeID, exists := c.Get("employee_id")
+// @Router /api/v2/departements/{id} [patch]
+func (ctl *DepartementController) UpdateDepartement(c *gin.Context) {
+	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
 	id := c.Param("id")
+	ifVersion, err := utils.IfMatch(c)
+	if err != nil {
+		return
+	}
+
 	var req DepartementPayload
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+	if err := utils.BindPayload(c, &req, validation.Update); err != nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tree, err := ctl.store.Departements().Tree(ctx)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to update departement"))
+		return
+	}
+
+	if _, ok := tree[id]; !ok {
+		c.Error(apperr.NotFound("departement not found"))
+		return
+	}
+
+	if err := ctl.validateDepartementTree(c, tree, id, req); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -252,73 +364,249 @@ func UpdateDepartement(c *gin.Context) {
 	if req.Name != nil {
 		payload["departement_name"] = *req.Name
 	}
+	if req.ParentID != nil {
+		payload["parent_id"] = nullIfEmpty(req.ParentID)
+	}
+	if req.HeadEmployeeID != nil {
+		payload["head_employee_id"] = nullIfEmpty(req.HeadEmployeeID)
+	}
 	if req.MaxClockInTime != nil {
-		payload["max_clock_in_time"] = *req.MaxClockInTime
+		payload["max_clock_in_time"] = nullIfEmpty(req.MaxClockInTime)
 	}
 	if req.MaxClockOutTime != nil {
-		payload["max_clock_out_time"] = *req.MaxClockOutTime
+		payload["max_clock_out_time"] = nullIfEmpty(req.MaxClockOutTime)
 	}
-
-	// Whitelist fields
-	whitelist := []string{"departement_name", "max_clock_in_time", "max_clock_out_time"}
-
-	// Audit fields
-	audit := map[string]interface{}{
-		"updated_at": time.Now(),
-		"updated_by": employeeID,
+	if req.Timezone != nil {
+		payload["timezone"] = nullIfEmpty(req.Timezone)
 	}
 
-	query, args, err := utils.BuildDynamicUpdateQuery("departement", payload, whitelist, audit)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var version int
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		if req.Name != nil {
+			if err := checkNameFree(ctx, tx, *req.Name, id); err != nil {
+				return err
+			}
+		}
+		changes, newVersion, err := tx.Departements().Update(ctx, id, payload, ifVersion, c.GetString("employee_id"), time.Now())
+		if err != nil {
+			return err
+		}
+		version = newVersion
+		return recordUpdate(tx, c, "departement", id, changes)
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("departement not found"))
 		return
-	}
-
-	args = append(args, id)
-
-	_, err = config.DB.Exec(query, args...)
-	if err != nil {
-		log.Println("Update departement error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update departement"})
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to update departement"))
 		return
 	}
+	if _, renamed := payload["departement_name"]; renamed {
+		warnReindex(c, ctl.search.RefreshDepartement(ctx, id))
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "departement updated"})
+	utils.SetETag(c, version)
+	c.JSON(http.StatusOK, gin.H{"message": "departement updated", "version": version})
 }
 
+var (
+	errDepartementHasChildren  = errors.New("departement still has child departements")
+	errDepartementHasEmployees = errors.New("departement still has active employees")
+)
+
 // DeleteDepartement godoc
 // @Summary Hapus departemen (soft delete)
-// @Description Menandai departemen sebagai terhapus tanpa menghapus data dari database. Autentikasi via JWT cookie.
+// @Description Menandai departemen sebagai terhapus tanpa menghapus data dari database. Ditolak (409) selama masih ada karyawan atau sub-departemen, kecuali reassign_to diisi: semua karyawan dipindahkan ke departemen tujuan dalam transaksi yang sama dan dicatat sebagai event transfer. Jika If-Match diisi, penghapusan ditolak (412) bila departemen sudah berubah. Autentikasi via JWT cookie.
 // @Tags Departement
 // @Produce json
 // @Param id path string true "ID Departemen"
-// @Success 200 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param If-Match header string false "ETag versi yang dihapus"
+// @Param reassign_to query string false "ID departemen tujuan untuk karyawan yang tersisa"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/departement/{id} [delete]
-func DeleteDepartement(c *gin.Context) {
-	userID, exists := c.Get("employee_id")
+func (ctl *DepartementController) DeleteDepartement(c *gin.Context) {
+	reassigned, ok := ctl.deleteDepartement(c)
+	if ok {
+		c.JSON(http.StatusOK, gin.H{"message": "departement deleted", "reassigned": reassigned})
+	}
+}
+
+// deleteDepartement soft deletes the departement of the request and returns
+// how many employees it moved to reassign_to.
+func (ctl *DepartementController) deleteDepartement(c *gin.Context) (int, bool) {
+	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return 0, false
 	}
 
 	id := c.Param("id")
+	ifVersion, err := utils.IfMatch(c)
+	if err != nil {
+		return 0, false
+	}
+
+	reassignTo := c.Query("reassign_to")
+	userID := c.GetString("employee_id")
+	ctx := c.Request.Context()
 	now := time.Now()
 
-	_, err := config.DB.Exec(`
-		UPDATE departement
-		SET deleted_at = ?, deleted_by = ?
-		WHERE id = ? AND deleted_at IS NULL
-	`, now, userID, id)
+	d, err := ctl.store.Departements().GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("departement not found"))
+		return 0, false
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to delete departement"))
+		return 0, false
+	}
+
+	if reassignTo != "" {
+		if reassignTo == id {
+			c.Error(apperr.Invalid("reassign_to", "different", "reassign_to must be a different departement"))
+			return 0, false
+		}
+		targetExists, err := ctl.store.Departements().Exists(ctx, reassignTo)
+		if err != nil {
+			c.Error(apperr.Internal(err, "failed to delete departement"))
+			return 0, false
+		}
+		if !targetExists {
+			c.Error(apperr.Invalid("reassign_to", "exists", "reassign_to departement not found"))
+			return 0, false
+		}
+	}
 
+	var employeeIDs []string
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		// Lock the children and the remaining employees so none can be
+		// added concurrently between the checks and the delete.
+		hasChildren, err := tx.Departements().HasChildren(ctx, id)
+		if err != nil {
+			return err
+		}
+		if hasChildren {
+			return errDepartementHasChildren
+		}
+
+		employeeIDs, err = tx.Employees().IDsInDepartement(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if len(employeeIDs) > 0 && reassignTo == "" {
+			return errDepartementHasEmployees
+		}
+
+		reason := fmt.Sprintf("departement %s deleted", d.DepartementName)
+		for _, empID := range employeeIDs {
+			changes, _, err := tx.Employees().Update(ctx, empID, map[string]interface{}{"departement_id": reassignTo}, 0, userID, now)
+			if err != nil {
+				return fmt.Errorf("reassign employee: %w", err)
+			}
+			if err := recordUpdate(tx, c, "employee", empID, changes); err != nil {
+				return fmt.Errorf("reassign employee: %w", err)
+			}
+
+			ev := model.EmployeeEvent{
+				EmployeeID:        empID,
+				EventType:         model.EmployeeEventTransfer,
+				EffectiveDate:     now.In(ctl.loc),
+				FromDepartementID: &id,
+				ToDepartementID:   &reassignTo,
+				Reason:            &reason,
+			}
+			ev.CreatedAt = now
+			ev.CreatedBy = userID
+			if err := tx.Employees().CreateEvent(ctx, ev); err != nil {
+				return fmt.Errorf("reassign employee: %w", err)
+			}
+		}
+
+		deleted, err := tx.Departements().SoftDelete(ctx, id, ifVersion, userID, now)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return repository.ErrNotFound
+		}
+
+		return recordAudit(tx, c, "departement", id, model.AuditActionDelete, map[string]model.AuditChange{
+			"deleted_at": {Old: nil, New: repository.NormalizeAuditValue(now)},
+		})
+	})
+	if err == errDepartementHasChildren {
+		c.Error(apperr.Conflict("departement still has child departements"))
+		return 0, false
+	} else if err == errDepartementHasEmployees {
+		c.Error(apperr.Conflict(fmt.Sprintf("departement still has %d active employees, pass reassign_to to move them", len(employeeIDs))))
+		return 0, false
+	} else if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("departement not found"))
+		return 0, false
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to delete departement"))
+		return 0, false
+	}
+	warnReindex(c, ctl.search.Refresh(ctx, employeeIDs...))
+
+	return len(employeeIDs), true
+}
+
+// GetDepartementTree godoc
+// @Summary Ambil struktur departemen dalam bentuk tree
+// @Description Mengembalikan semua departemen aktif sebagai tree (divisi → departemen → tim) beserta jam masuk/keluar efektif hasil pewarisan dari parent. Autentikasi via JWT cookie.
+// @Tags Departement
+// @Produce json
+// @Success 200 {array} model.DepartementTreeNode
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/departement/tree [get]
+// @Router /api/v2/departements/tree [get]
+func (ctl *DepartementController) GetDepartementTree(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	departements, err := ctl.store.Departements().All(c.Request.Context())
 	if err != nil {
-		log.Println("Delete departement error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete departement"})
+		c.Error(apperr.Internal(err, "failed to fetch departements"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "departement deleted"})
+	byID := map[string]model.Departement{}
+	for _, d := range departements {
+		byID[d.ID] = d
+	}
+
+	children := map[string][]string{}
+	for _, d := range departements {
+		parent := derefString(d.ParentID)
+		if _, ok := byID[parent]; !ok {
+			parent = ""
+		}
+		children[parent] = append(children[parent], d.ID)
+	}
+
+	var build func(parent string) []model.DepartementTreeNode
+	build = func(parent string) []model.DepartementTreeNode {
+		nodes := []model.DepartementTreeNode{}
+		for _, id := range children[parent] {
+			nodes = append(nodes, model.DepartementTreeNode{
+				Departement: byID[id],
+				Children:    build(id),
+			})
+		}
+		return nodes
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": build("")})
 }
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/repository/memstore"
+	"manajemen-karyawan-api/service/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newDepartementRouter(t *testing.T) (*gin.Engine, *memstore.Store) {
+	store := memstore.New(nil)
+	seedDepartement(t, store, "dep-it", "IT", "08:00:00", "17:00:00")
+
+	ctl := NewDepartementController(store, search.NewIndex(store), time.UTC)
+	r := newTestRouter()
+	departement := r.Group("/api/departement")
+	departement.POST("", ctl.CreateDepartement)
+	departement.PUT("/:id", ctl.UpdateDepartement)
+	departement.DELETE("/:id", ctl.DeleteDepartement)
+	departement.POST("/:id/restore", ctl.RestoreDepartement)
+	return r, store
+}
+
+func TestDepartementNameUnique(t *testing.T) {
+	r, _ := newDepartementRouter(t)
+
+	root := func(name string) DepartementPayload {
+		return DepartementPayload{Name: strPtr(name), MaxClockInTime: strPtr("08:00:00"), MaxClockOutTime: strPtr("17:00:00")}
+	}
+
+	do(t, r, http.MethodPost, "/api/departement", root("it")).
+		expectProblem(http.StatusConflict, apperr.CodeConflict)
+
+	var created struct{ ID string }
+	do(t, r, http.MethodPost, "/api/departement", root("HR")).
+		expect(http.StatusOK).decode(&created)
+
+	do(t, r, http.MethodPut, "/api/departement/"+created.ID, DepartementPayload{Name: strPtr("IT")}).
+		expectProblem(http.StatusConflict, apperr.CodeConflict)
+	// Renaming a departement to its own name, in another case, is fine
+	do(t, r, http.MethodPut, "/api/departement/"+created.ID, DepartementPayload{Name: strPtr("hr")}).
+		expect(http.StatusOK)
+
+	// A deleted departement frees its name until it is restored
+	do(t, r, http.MethodDelete, "/api/departement/dep-it", nil).expect(http.StatusOK)
+	do(t, r, http.MethodPut, "/api/departement/"+created.ID, DepartementPayload{Name: strPtr("IT")}).
+		expect(http.StatusOK)
+	do(t, r, http.MethodPost, "/api/departement/dep-it/restore", nil).
+		expectProblem(http.StatusConflict, apperr.CodeConflict)
+}
@@ -1,40 +1,50 @@
 package controller
 
 import (
-	"database/sql"
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
 	"time"
 
-	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/apperr"
 	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/service/search"
 	"manajemen-karyawan-api/utils"
+	"manajemen-karyawan-api/validation"
 
 	"github.com/gin-gonic/gin"
 )
 
-var allowedEmployeeFields = map[string]string{
-	"employeeID":     "e.employee_id",
-	"departmentName": "d.departement_name",
-	"name":           "e.name",
-	"address":        "e.address",
+type EmployeeController struct {
+	store  repository.Store
+	search *search.Index
+	// Initial password of created and imported employees
+	defaultPassword string
+	// Business timezone; events effective "today" are dated in it
+	loc *time.Location
+}
+
+func NewEmployeeController(store repository.Store, index *search.Index, defaultPassword string, loc *time.Location) *EmployeeController {
+	return &EmployeeController{store: store, search: index, defaultPassword: defaultPassword, loc: loc}
 }
 
 // GetAllEmployees godoc
 // @Summary Ambil semua karyawan aktif
 // @Description Mengembalikan list semua karyawan aktif. Autentikasi via JWT cookie.
 // @Tags Employee
+// @Accept json
 // @Produce json
+// @Param payload body utils.QueryParams false "page, per_page, sort_by, filter, cursor dan with_total (lihat README)"
 // @Success 200 {array} model.Employee
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/employee [POST]
-func GetAllEmployees(c *gin.Context) {
+func (ctl *EmployeeController) GetAllEmployees(c *gin.Context) {
 	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
@@ -42,87 +52,21 @@ func GetAllEmployees(c *gin.Context) {
 	if err := utils.BindJSONStrict(c, &params); err != nil {
 		return
 	}
+	params.LenientSort = true
+	ctl.listEmployees(c, params)
+}
 
-	sortSQL := utils.BuildSortSQL(params.SortBy, allowedEmployeeFields)
-	pagination := utils.BuildPagination(params.Page, params.PerPage)
-
-	filterSQL, filterArgs := utils.BuildFilterSQL(params.Filter, allowedEmployeeFields)
-
-	// Build query
-	query := fmt.Sprintf(`
-		SELECT 
-		e.id, 
-		e.employee_id,
-		e.departement_id, 
-		d.departement_name, 
-		e.name, 
-		e.address
-		FROM employee e
-		JOIN departement d ON e.departement_id = d.id
-		WHERE e.deleted_at IS NULL
-		%s
-		%s
-	`, filterSQL, sortSQL)
-
-	var args []interface{}
-	args = append(args, filterArgs...)
-
-	if pagination.Use {
-		query += " LIMIT ? OFFSET ?"
-		args = append(args, pagination.Limit, pagination.Offset)
-	}
-
-	rows, err := config.DB.Query(query, args...)
+func (ctl *EmployeeController) listEmployees(c *gin.Context, params utils.QueryParams) {
+	result, page, err := ctl.store.Employees().List(c.Request.Context(), params)
 	if err != nil {
-		log.Println("Employee query error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch employees"})
+		c.Error(apperr.Internal(err, "failed to fetch employees"))
 		return
 	}
-	defer rows.Close()
-
-	var result []struct {
-		model.Employee
-	}
-	for rows.Next() {
-		var row struct {
-			model.Employee
-		}
-		err := rows.Scan(
-			&row.ID, &row.EmployeeID, &row.DepartementID,
-			&row.DepartementName,
-			&row.Name, &row.Address,
-		)
-		if err != nil {
-			log.Println("Employee scan error:", err)
-			continue
-		}
-		result = append(result, row)
-	}
-	// Count total with filter
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) FROM employee
-		WHERE deleted_at IS NULL
-		%s
-	`, filterSQL)
-
-	var total int
-	err = config.DB.QueryRow(countQuery, filterArgs...).Scan(&total)
-	if err != nil {
-		log.Println("Employee count error:", err)
-		total = 0
-	}
 
 	// Response
-	meta := utils.BuildMeta(utils.MetaParams{
-		Page:    params.Page,
-		PerPage: params.PerPage,
-		Total:   total,
-		SortBy:  params.SortBy,
-	})
-
 	c.JSON(http.StatusOK, gin.H{
 		"data": result,
-		"meta": meta,
+		"meta": page.Meta(params),
 	})
 }
 
@@ -133,46 +77,59 @@ func GetAllEmployees(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Employee ID"
 // @Success 200 {object} model.Employee
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Versi data, dikirim kembali lewat If-Match saat update/hapus"
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/employee/{id} [get]
-func GetEmployeeByID(c *gin.Context) {
+// @Router /api/v2/employees/{id} [get]
+func (ctl *EmployeeController) GetEmployeeByID(c *gin.Context) {
 	_, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 	id := c.Param("id")
 
-	var e model.Employee
-	query := `
-		SELECT id, departement_id, name, address
-		FROM employee
-		WHERE id = ? AND deleted_at IS NULL
-	`
-	err := config.DB.QueryRow(query, id).Scan(
-		&e.ID, &e.DepartementID, &e.Name, &e.Address,
-	)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "employee not found"})
+	e, err := ctl.store.Employees().GetByID(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("employee not found"))
 		return
 	} else if err != nil {
-		log.Println("Employee detail error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		c.Error(apperr.Internal(err, "failed to fetch employee"))
 		return
 	}
 
+	utils.SetETag(c, e.Version)
 	c.JSON(http.StatusOK, e)
 }
 
+// EmployeePayload is the body of both create and update; fields left out of
+// an update keep their value. See package validation for the rules.
 type EmployeePayload struct {
-	EmployeeID    *string `json:"employeeID,omitempty"`
-	Name          *string `json:"name,omitempty"`
-	DepartementID *string `json:"departmentID,omitempty"`
-	Address       *string `json:"address,omitempty"`
+	EmployeeID    *string `json:"employeeID,omitempty" validate:"required_on_create,omitempty,notblank,max=50"`
+	Name          *string `json:"name,omitempty" validate:"required_on_create,omitempty,notblank,max=255"`
+	DepartementID *string `json:"departmentID,omitempty" validate:"required_on_create,omitempty,notblank,max=50"`
+	Address       *string `json:"address,omitempty" validate:"omitempty,max=1000"`
+	Position      *string `json:"position,omitempty" validate:"omitempty,max=255"`
+	HireDate      *string `json:"hireDate,omitempty" validate:"omitempty,date"`
+}
+
+// checkDepartementExists reports departmentID as invalid when it names no
+// active departement.
+func (ctl *EmployeeController) checkDepartementExists(c *gin.Context, departementID *string) error {
+	if departementID == nil {
+		return nil
+	}
+	ok, err := ctl.store.Departements().Exists(c.Request.Context(), *departementID)
+	if err != nil {
+		return apperr.Internal(err, "failed to check departement")
+	}
+	if !ok {
+		return apperr.Invalid("departmentID", "exists", "departement not found")
+	}
+	return nil
 }
 
 // CreateEmployee godoc
@@ -183,168 +140,295 @@ type EmployeePayload struct {
 // @Produce json
 // @Param payload body EmployeePayload true "Data karyawan"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/employee [post]
-func CreateEmployee(c *gin.Context) {
+func (ctl *EmployeeController) CreateEmployee(c *gin.Context) {
+	id, ok := ctl.createEmployee(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "employee created", "id": id})
+}
+
+// createEmployee creates an employee from the request and returns its ID;
+// on failure the error is already attached to c.
+func (ctl *EmployeeController) createEmployee(c *gin.Context) (string, bool) {
 	employeeID, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return "", false
 	}
 
 	var req EmployeePayload
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
-		return
+	if err := utils.BindPayload(c, &req, validation.Create); err != nil {
+		return "", false
 	}
 
-	fmt.Println(*req.EmployeeID)
-
-	existsEmp := false
-	err := config.DB.QueryRow(`
-		SELECT EXISTS (
-			SELECT 1 FROM employee WHERE employee_id = ? AND deleted_at IS NULL
-		)
-	`, req.EmployeeID).Scan(&existsEmp)
+	ctx := c.Request.Context()
+	if err := ctl.checkDepartementExists(c, req.DepartementID); err != nil {
+		c.Error(err)
+		return "", false
+	}
 
+	existsEmp, err := ctl.store.Employees().EmployeeIDTaken(ctx, derefString(req.EmployeeID))
 	if err != nil {
-		log.Println("Check employee_id error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
-		return
+		c.Error(apperr.Internal(err, "failed to check employee ID"))
+		return "", false
 	}
 
 	if existsEmp {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Employee ID already exists"})
-		return
+		c.Error(apperr.Conflict("employee ID already exists, possibly on a deleted employee that can be restored"))
+		return "", false
 	}
 
-	id := utils.GenerateID()
 	now := time.Now()
-	pass, err := utils.CreatePassword("password123")
+
+	hireDate := now.In(ctl.loc)
+	if req.HireDate != nil {
+		hireDate, _ = time.Parse(validation.DateLayout, *req.HireDate)
+	}
+
+	pass, err := utils.CreatePassword(ctl.defaultPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
-		return
+		c.Error(apperr.Internal(err, "failed to hash password"))
+		return "", false
 	}
 
-	_, err = config.DB.Exec(`
-		INSERT INTO employee (id, employee_id, departement_id, name, address, password, created_at, created_by)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, id, req.EmployeeID, req.DepartementID, req.Name, req.Address, pass, now, employeeID)
+	emp := model.Employee{
+		ID:            utils.GenerateID(),
+		EmployeeID:    derefString(req.EmployeeID),
+		DepartementID: derefString(req.DepartementID),
+		Name:          derefString(req.Name),
+		Address:       derefString(req.Address),
+		Position:      req.Position,
+		Status:        model.EmployeeStatusActive,
+		Password:      pass,
+	}
+	emp.CreatedAt = now
+	emp.CreatedBy, _ = employeeID.(string)
+
+	hire := model.EmployeeEvent{
+		EmployeeID:      emp.ID,
+		EventType:       model.EmployeeEventHire,
+		EffectiveDate:   hireDate,
+		ToDepartementID: req.DepartementID,
+		ToPosition:      req.Position,
+	}
+	hire.CreatedAt = now
+	hire.CreatedBy = emp.CreatedBy
 
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Employees().Create(ctx, emp); err != nil {
+			return err
+		}
+		if err := recordAudit(tx, c, "employee", emp.ID, model.AuditActionCreate, employeeCreateChanges(emp)); err != nil {
+			return err
+		}
+		return tx.Employees().CreateEvent(ctx, hire)
+	})
 	if err != nil {
-		log.Println("Create employee error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create employee"})
-		return
+		c.Error(apperr.Internal(err, "failed to create employee"))
+		return "", false
 	}
+	warnReindex(c, ctl.search.Refresh(ctx, emp.ID))
 
-	c.JSON(http.StatusOK, gin.H{"message": "employee created", "id": id})
+	return emp.ID, true
+}
+
+// employeeCreateChanges lists the audited fields of a new employee.
+func employeeCreateChanges(e model.Employee) map[string]model.AuditChange {
+	return repository.DiffValues(nil, map[string]interface{}{
+		"employee_id":    e.EmployeeID,
+		"departement_id": e.DepartementID,
+		"name":           e.Name,
+		"address":        e.Address,
+		"position":       e.Position,
+		"status":         e.Status,
+	})
 }
 
 // UpdateEmployee godoc
 // @Summary Update data karyawan
-// @Description Mengubah data karyawan berdasarkan ID. Autentikasi via JWT cookie.
+// @Description Mengubah data karyawan berdasarkan ID. Jika If-Match diisi dengan ETag dari GET, perubahan ditolak (412) bila data sudah diubah orang lain. Autentikasi via JWT cookie.
 // @Tags Employee
 // @Accept json
 // @Produce json
 // @Param id path string true "ID Karyawan"
+// @Param If-Match header string false "ETag versi yang diubah"
 // @Param payload body EmployeePayload true "Data karyawan"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "Versi data setelah update"
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/employee/{id} [put]
-func UpdateEmployee(c *gin.Context) {
+// @Router /api/v2/employees/{id} [patch]
+func (ctl *EmployeeController) UpdateEmployee(c *gin.Context) {
 	employeeID, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperr.Unauthorized("unauthorized"))
 		return
 	}
 
 	id := c.Param("id")
+	ifVersion, err := utils.IfMatch(c)
+	if err != nil {
+		return
+	}
 
 	var req EmployeePayload
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+	if err := utils.BindPayload(c, &req, validation.Update); err != nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := ctl.store.Employees().GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("employee not found"))
 		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch employee"))
+		return
+	}
+
+	if req.DepartementID != nil && *req.DepartementID != current.DepartementID {
+		if err := ctl.checkDepartementExists(c, req.DepartementID); err != nil {
+			c.Error(err)
+			return
+		}
 	}
 
+	now := time.Now()
+	var events []model.EmployeeEvent
+
 	payload := map[string]interface{}{}
 	if req.Name != nil {
 		payload["name"] = *req.Name
 	}
-	if req.DepartementID != nil {
+	if req.DepartementID != nil && *req.DepartementID != current.DepartementID {
 		payload["departement_id"] = *req.DepartementID
+		events = append(events, model.EmployeeEvent{
+			EventType:         model.EmployeeEventTransfer,
+			FromDepartementID: &current.DepartementID,
+			ToDepartementID:   req.DepartementID,
+		})
 	}
 	if req.Address != nil {
 		payload["address"] = *req.Address
 	}
-
-	// Whitelist fields
-	whitelist := []string{"name", "departement_id", "address"}
-
-	// Audit fields
-	audit := map[string]interface{}{
-		"updated_at": time.Now(),
-		"updated_by": employeeID,
+	if req.Position != nil && (current.Position == nil || *req.Position != *current.Position) {
+		payload["position"] = *req.Position
+		events = append(events, model.EmployeeEvent{
+			EventType:    model.EmployeeEventPromotion,
+			FromPosition: current.Position,
+			ToPosition:   req.Position,
+		})
 	}
 
-	query, args, err := utils.BuildDynamicUpdateQuery("employee", payload, whitelist, audit)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	args = append(args, id)
+	userID, _ := employeeID.(string)
+	var version int
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		changes, newVersion, err := tx.Employees().Update(ctx, id, payload, ifVersion, userID, now)
+		if err != nil {
+			return err
+		}
+		version = newVersion
+		if err := recordUpdate(tx, c, "employee", id, changes); err != nil {
+			return err
+		}
 
-	_, err = config.DB.Exec(query, args...)
-	if err != nil {
-		log.Println("Update employee error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update employee"})
+		// Departement and position changes made through a plain update are
+		// recorded as transfer/promotion events effective today.
+		for _, ev := range events {
+			ev.EmployeeID = id
+			ev.EffectiveDate = now.In(ctl.loc)
+			ev.CreatedAt = now
+			ev.CreatedBy = userID
+			if err := tx.Employees().CreateEvent(ctx, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("employee not found"))
+		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to update employee"))
 		return
 	}
+	warnReindex(c, ctl.search.Refresh(ctx, id))
 
-	c.JSON(http.StatusOK, gin.H{"message": "employee updated"})
+	utils.SetETag(c, version)
+	c.JSON(http.StatusOK, gin.H{"message": "employee updated", "version": version})
 }
 
 // DeleteEmployee godoc
 // @Summary Hapus karyawan (soft delete)
-// @Description Menandai karyawan sebagai terhapus tanpa menghapus data dari database. Autentikasi via JWT cookie.
+// @Description Menandai karyawan sebagai terhapus tanpa menghapus data dari database. Jika If-Match diisi, penghapusan ditolak (412) bila data sudah berubah. Autentikasi via JWT cookie.
 // @Tags Employee
 // @Produce json
 // @Param id path string true "ID Karyawan"
+// @Param If-Match header string false "ETag versi yang dihapus"
 // @Success 200 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 403 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router /api/employee/{id} [delete]
+func (ctl *EmployeeController) DeleteEmployee(c *gin.Context) {
+	if ctl.deleteEmployee(c) {
+		c.JSON(http.StatusOK, gin.H{"message": "employee deleted"})
+	}
+}
 
-func DeleteEmployee(c *gin.Context) {
+// deleteEmployee soft deletes the employee of the request and reports
+// whether it did.
+func (ctl *EmployeeController) deleteEmployee(c *gin.Context) bool {
 	employeeID, exists := c.Get("employee_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return false
 	}
 
 	id := c.Param("id")
-	now := time.Now()
+	ifVersion, err := utils.IfMatch(c)
+	if err != nil {
+		return false
+	}
 
-	_, err := config.DB.Exec(`
-		UPDATE employee
-		SET deleted_at = ?, deleted_by = ?
-		WHERE id = ? AND deleted_at IS NULL
-	`, now, employeeID, id)
+	now := time.Now()
+	ctx := c.Request.Context()
+	userID, _ := employeeID.(string)
 
-	if err != nil {
-		log.Println("Delete employee error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete employee"})
-		return
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		deleted, err := tx.Employees().SoftDelete(ctx, id, ifVersion, userID, now)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return repository.ErrNotFound
+		}
+		return recordAudit(tx, c, "employee", id, model.AuditActionDelete, map[string]model.AuditChange{
+			"deleted_at": {Old: nil, New: repository.NormalizeAuditValue(now)},
+		})
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("employee not found"))
+		return false
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to delete employee"))
+		return false
 	}
+	warnReindex(c, ctl.search.Refresh(ctx, id))
 
-	c.JSON(http.StatusOK, gin.H{"message": "employee deleted"})
+	return true
 }
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+	"manajemen-karyawan-api/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEmployeeEvents godoc
+// @Summary Riwayat lifecycle karyawan
+// @Description Menampilkan riwayat hire, mutasi, promosi, resign dan terminasi karyawan berdasarkan ID, diurutkan dari tanggal efektif terlama. Autentikasi via JWT cookie.
+// @Tags Employee
+// @Produce json
+// @Param id path string true "ID Karyawan"
+// @Success 200 {array} model.EmployeeEvent
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/employee/{id}/events [get]
+// @Router /api/v2/employees/{id}/events [get]
+func (ctl *EmployeeController) GetEmployeeEvents(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := c.Param("id")
+
+	ctx := c.Request.Context()
+	existsEmp, err := ctl.store.Employees().Exists(ctx, id)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch employee"))
+		return
+	}
+	if !existsEmp {
+		c.Error(apperr.NotFound("employee not found"))
+		return
+	}
+
+	result, err := ctl.store.Employees().ListEvents(ctx, id)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch employee events"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+type EmployeeEventPayload struct {
+	EventType     string  `json:"eventType" binding:"required"`
+	EffectiveDate string  `json:"effectiveDate" binding:"required" validate:"date"`
+	DepartementID *string `json:"departementID,omitempty" validate:"omitempty,max=50"`
+	Position      *string `json:"position,omitempty" validate:"omitempty,max=255"`
+	Reason        *string `json:"reason,omitempty" validate:"omitempty,max=1000"`
+}
+
+// CreateEmployeeEvent godoc
+// @Summary Catat event lifecycle karyawan
+// @Description Mencatat mutasi (transfer), promosi, resign, terminasi atau rehire (hire) dengan tanggal efektif (YYYY-MM-DD, tidak boleh sebelum event terakhir), lalu menerapkan perubahan ke data karyawan. Terminasi wajib menyertakan reason. Autentikasi via JWT cookie.
+// @Tags Employee
+// @Accept json
+// @Produce json
+// @Param id path string true "ID Karyawan"
+// @Param payload body EmployeeEventPayload true "Data event"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/employee/{id}/events [post]
+// @Router /api/v2/employees/{id}/events [post]
+func (ctl *EmployeeController) CreateEmployeeEvent(c *gin.Context) {
+	userID, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := c.Param("id")
+
+	var req EmployeeEventPayload
+	if err := utils.BindPayload(c, &req, validation.Create); err != nil {
+		return
+	}
+
+	effectiveDate, _ := time.Parse(validation.DateLayout, req.EffectiveDate)
+	now := time.Now()
+	if effectiveDate.After(now) {
+		c.Error(apperr.Invalid("effectiveDate", "past", "effectiveDate cannot be in the future"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	emp, err := ctl.store.Employees().GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("employee not found"))
+		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch employee"))
+		return
+	}
+
+	// The event is applied to the employee right away, so one dated before
+	// the latest event would overwrite the newer state with an older one.
+	events, err := ctl.store.Employees().ListEvents(ctx, id)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch employee events"))
+		return
+	}
+	if n := len(events); n > 0 {
+		if latest := events[n-1].EffectiveDate.Format(validation.DateLayout); req.EffectiveDate < latest {
+			c.Error(apperr.Invalid("effectiveDate", "after", fmt.Sprintf("effectiveDate cannot be before the latest event on %s", latest)))
+			return
+		}
+	}
+
+	ev := model.EmployeeEvent{
+		EmployeeID:    id,
+		EventType:     strings.ToLower(req.EventType),
+		EffectiveDate: effectiveDate,
+		Reason:        req.Reason,
+	}
+	ev.CreatedAt = now
+	ev.CreatedBy, _ = userID.(string)
+
+	departementID, position, status := emp.DepartementID, emp.Position, emp.Status
+	update := map[string]interface{}{}
+
+	switch ev.EventType {
+	case model.EmployeeEventHire:
+		if status == model.EmployeeStatusActive {
+			c.Error(apperr.Conflict("employee is already active"))
+			return
+		}
+		update["status"] = model.EmployeeStatusActive
+		ev.ToDepartementID = &departementID
+		if req.DepartementID != nil && *req.DepartementID != "" {
+			update["departement_id"] = *req.DepartementID
+			ev.ToDepartementID = req.DepartementID
+		}
+	case model.EmployeeEventTransfer:
+		if req.DepartementID == nil || *req.DepartementID == "" {
+			c.Error(apperr.Invalid("departementID", "required", "departementID is required for transfer"))
+			return
+		}
+		if *req.DepartementID == departementID {
+			c.Error(apperr.Conflict("employee is already in this departement"))
+			return
+		}
+		update["departement_id"] = *req.DepartementID
+		ev.FromDepartementID = &departementID
+		ev.ToDepartementID = req.DepartementID
+	case model.EmployeeEventPromotion:
+		if req.Position == nil || *req.Position == "" {
+			c.Error(apperr.Invalid("position", "required", "position is required for promotion"))
+			return
+		}
+		update["position"] = *req.Position
+		ev.FromPosition = position
+		ev.ToPosition = req.Position
+		if req.DepartementID != nil && *req.DepartementID != "" && *req.DepartementID != departementID {
+			update["departement_id"] = *req.DepartementID
+			ev.FromDepartementID = &departementID
+			ev.ToDepartementID = req.DepartementID
+		}
+	case model.EmployeeEventResignation:
+		update["status"] = model.EmployeeStatusResigned
+	case model.EmployeeEventTermination:
+		if req.Reason == nil || strings.TrimSpace(*req.Reason) == "" {
+			c.Error(apperr.Invalid("reason", "required", "reason is required for termination"))
+			return
+		}
+		update["status"] = model.EmployeeStatusTerminated
+	default:
+		c.Error(apperr.Invalid("eventType", "oneof", "invalid eventType"))
+		return
+	}
+
+	if ev.EventType != model.EmployeeEventHire && status != model.EmployeeStatusActive {
+		c.Error(apperr.Conflict("employee is not active"))
+		return
+	}
+
+	if newDept, ok := update["departement_id"].(string); ok {
+		existsDept, err := ctl.store.Departements().Exists(ctx, newDept)
+		if err != nil {
+			c.Error(apperr.Internal(err, "failed to check departement"))
+			return
+		}
+		if !existsDept {
+			c.Error(apperr.Invalid("departementID", "exists", "departement not found"))
+			return
+		}
+	}
+
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		changes, _, err := tx.Employees().Update(ctx, id, update, 0, ev.CreatedBy, now)
+		if err != nil {
+			return err
+		}
+		if err := recordUpdate(tx, c, "employee", id, changes); err != nil {
+			return err
+		}
+		return tx.Employees().CreateEvent(ctx, ev)
+	})
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to save employee event"))
+		return
+	}
+	warnReindex(c, ctl.search.Refresh(ctx, id))
+
+	c.JSON(http.StatusOK, gin.H{"message": "employee event recorded"})
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+)
+
+func TestCreateEmployeeEventOrder(t *testing.T) {
+	r, store := newEmployeeRouter(t)
+	id := seedEmployee(t, store, "EMP001", "dep-it")
+	post := func(payload EmployeeEventPayload) testResponse {
+		return do(t, r, http.MethodPost, "/api/employee/"+id+"/events", payload)
+	}
+
+	post(EmployeeEventPayload{EventType: model.EmployeeEventTransfer, EffectiveDate: "2024-03-01", DepartementID: strPtr("dep-hr")}).
+		expect(http.StatusOK)
+
+	// A backdated transfer back to IT would leave the employee in IT even
+	// though the history says HR since March
+	p := post(EmployeeEventPayload{EventType: model.EmployeeEventTransfer, EffectiveDate: "2024-02-01", DepartementID: strPtr("dep-it")}).
+		expectProblem(http.StatusBadRequest, apperr.CodeValidationFailed)
+	if len(p.Errors) != 1 || p.Errors[0].Field != "effectiveDate" {
+		t.Errorf("errors = %+v", p.Errors)
+	}
+	e, err := store.Employees().GetByID(context.Background(), id)
+	if err != nil || e.DepartementID != "dep-hr" {
+		t.Errorf("employee after rejected event = %+v, %v", e, err)
+	}
+
+	// Events on the same day as the latest one are fine
+	post(EmployeeEventPayload{EventType: model.EmployeeEventPromotion, EffectiveDate: "2024-03-01", Position: strPtr("Lead")}).
+		expect(http.StatusOK)
+	if events, _ := store.Employees().ListEvents(context.Background(), id); len(events) != 2 {
+		t.Errorf("events = %+v", events)
+	}
+}
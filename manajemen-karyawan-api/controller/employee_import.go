@@ -0,0 +1,325 @@
+package controller
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+	"manajemen-karyawan-api/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const maxImportFileSize = 10 << 20
+
+// importColumns maps accepted header spellings to a canonical column name.
+var importColumns = map[string]string{
+	"employee_id":      "employee_id",
+	"employeeid":       "employee_id",
+	"name":             "name",
+	"departement":      "departement",
+	"departement_name": "departement",
+	"departementname":  "departement",
+	"address":          "address",
+	"position":         "position",
+	"hire_date":        "hire_date",
+	"hiredate":         "hire_date",
+}
+
+// importPayloadColumns names the import column of each EmployeePayload field
+// whose rules apply to the cells.
+var importPayloadColumns = map[string]string{
+	"employeeID": "employee_id",
+	"name":       "name",
+	"address":    "address",
+	"position":   "position",
+}
+
+type importRow struct {
+	line          int
+	employeeID    string
+	name          string
+	departementID string
+	address       string
+	position      string
+	hireDate      time.Time
+}
+
+func readImportFile(file multipart.File, filename string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		r := csv.NewReader(file)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		return r.ReadAll()
+	case ".xlsx":
+		f, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("workbook has no sheets")
+		}
+		return f.GetRows(sheets[0])
+	default:
+		return nil, errors.New("file must be .csv or .xlsx")
+	}
+}
+
+// ImportEmployees godoc
+// @Summary Import karyawan dari CSV/XLSX
+// @Description Mengimpor banyak karyawan sekaligus dari file CSV atau XLSX (sheet pertama). Kolom: employee_id, name, departement, address, position, hire_date (YYYY-MM-DD). Setiap baris divalidasi (field wajib, panjang maksimal seperti create, employee_id duplikat, nama departemen tidak dikenal). Dengan dry_run=true hanya mengembalikan laporan error per baris; tanpa dry_run semua baris disimpan dalam satu transaksi jika file valid. Autentikasi via JWT cookie.
+// @Tags Employee
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File CSV/XLSX"
+// @Param dry_run query bool false "Hanya validasi tanpa menyimpan"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/employee/import [post]
+// @Router /api/v2/employees/import [post]
+func (ctl *EmployeeController) ImportEmployees(c *gin.Context) {
+	userID, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true" || c.Query("dry_run") == "1"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.Error(apperr.Invalid("file", "required", "file is required"))
+		return
+	}
+	defer file.Close()
+
+	records, err := readImportFile(file, header.Filename)
+	if err != nil {
+		c.Error(apperr.Invalid("file", "format", fmt.Sprintf("failed to read file: %v", err)))
+		return
+	}
+	if len(records) < 2 {
+		c.Error(apperr.Invalid("file", "empty", "file has no data rows"))
+		return
+	}
+
+	columns := map[string]int{}
+	for i, h := range records[0] {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if col, ok := importColumns[key]; ok {
+			columns[col] = i
+		}
+	}
+	for _, required := range []string{"employee_id", "name", "departement"} {
+		if _, ok := columns[required]; !ok {
+			c.Error(apperr.Invalid("file", "column", fmt.Sprintf("missing column %s", required)))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	departements, err := ctl.store.Departements().IDsByName(ctx)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to import employees"))
+		return
+	}
+
+	existing, err := ctl.store.Employees().EmployeeIDsInUse(ctx)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to import employees"))
+		return
+	}
+
+	now := time.Now()
+	rowErrors := []apperr.FieldError{}
+	seen := map[string]int{}
+	var rows []importRow
+
+	for i, record := range records[1:] {
+		line := i + 2
+		cell := func(col string) string {
+			idx, ok := columns[col]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		row := importRow{
+			line:       line,
+			employeeID: cell("employee_id"),
+			name:       cell("name"),
+			address:    cell("address"),
+			position:   cell("position"),
+			hireDate:   now.In(ctl.loc),
+		}
+		// Fields are reported as rows[N].column, N being the line in the file
+		addErr := func(field, code, msg string) {
+			rowErrors = append(rowErrors, apperr.FieldError{
+				Field:   fmt.Sprintf("rows[%d].%s", line, field),
+				Code:    code,
+				Message: msg,
+			})
+		}
+
+		// Lengths follow the rules of the create payload. Empty cells are
+		// left out; the required ones are reported below.
+		payload := EmployeePayload{
+			EmployeeID: nullIfEmpty(&row.employeeID),
+			Name:       nullIfEmpty(&row.name),
+			Address:    nullIfEmpty(&row.address),
+			Position:   nullIfEmpty(&row.position),
+		}
+		if err := validation.Struct(ctx, validation.Update, payload); err != nil {
+			var invalid *apperr.Error
+			if !errors.As(err, &invalid) {
+				c.Error(apperr.Internal(err, "failed to import employees"))
+				return
+			}
+			for _, fe := range invalid.Fields {
+				addErr(importPayloadColumns[fe.Field], fe.Code, strings.TrimPrefix(fe.Message, fe.Field+" "))
+			}
+		}
+
+		if row.employeeID == "" {
+			addErr("employee_id", "required", "required")
+		} else if first, dup := seen[strings.ToLower(row.employeeID)]; dup {
+			addErr("employee_id", "duplicate", fmt.Sprintf("duplicate of row %d", first))
+		} else if deleted, ok := existing[strings.ToLower(row.employeeID)]; ok {
+			if deleted {
+				addErr("employee_id", "unique", "already used by a deleted employee")
+			} else {
+				addErr("employee_id", "unique", "already exists")
+			}
+		} else {
+			seen[strings.ToLower(row.employeeID)] = line
+		}
+
+		if row.name == "" {
+			addErr("name", "required", "required")
+		}
+
+		if name := cell("departement"); name == "" {
+			addErr("departement", "required", "required")
+		} else if id, ok := departements[strings.ToLower(name)]; !ok {
+			addErr("departement", "exists", fmt.Sprintf("unknown departement %q", name))
+		} else {
+			row.departementID = id
+		}
+
+		if raw := cell("hire_date"); raw != "" {
+			hireDate, err := time.Parse(validation.DateLayout, raw)
+			if err != nil {
+				addErr("hire_date", "date", "must be YYYY-MM-DD")
+			} else {
+				row.hireDate = hireDate
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	report := gin.H{
+		"dryRun": dryRun,
+		"total":  len(rows),
+		"errors": rowErrors,
+	}
+
+	if len(rowErrors) > 0 {
+		invalid := apperr.Validation(rowErrors...)
+		invalid.Detail = fmt.Sprintf("file contains invalid rows (%d errors in %d rows)", len(rowErrors), len(rows))
+		c.Error(invalid)
+		return
+	}
+
+	if dryRun {
+		report["message"] = "file is valid"
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	pass, err := utils.CreatePassword(ctl.defaultPassword)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to hash password"))
+		return
+	}
+
+	createdBy, _ := userID.(string)
+	var failedRow int
+	imported := make([]string, 0, len(rows))
+	err = ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		for _, row := range rows {
+			failedRow = row.line
+			emp := model.Employee{
+				ID:            utils.GenerateID(),
+				EmployeeID:    row.employeeID,
+				DepartementID: row.departementID,
+				Name:          row.name,
+				Address:       row.address,
+				Position:      nullIfEmpty(&row.position),
+				Status:        model.EmployeeStatusActive,
+				Password:      pass,
+			}
+			emp.CreatedAt = now
+			emp.CreatedBy = createdBy
+
+			if err := tx.Employees().Create(ctx, emp); err != nil {
+				return err
+			}
+			imported = append(imported, emp.ID)
+			if err := recordAudit(tx, c, "employee", emp.ID, model.AuditActionCreate, employeeCreateChanges(emp)); err != nil {
+				return err
+			}
+
+			hire := model.EmployeeEvent{
+				EmployeeID:      emp.ID,
+				EventType:       model.EmployeeEventHire,
+				EffectiveDate:   row.hireDate,
+				ToDepartementID: &emp.DepartementID,
+				ToPosition:      emp.Position,
+			}
+			hire.CreatedAt = now
+			hire.CreatedBy = createdBy
+
+			if err := tx.Employees().CreateEvent(ctx, hire); err != nil {
+				return err
+			}
+		}
+		failedRow = 0
+		return nil
+	})
+	if err != nil {
+		if failedRow > 0 {
+			c.Error(apperr.Internal(err, fmt.Sprintf("failed to import row %d", failedRow)))
+		} else {
+			c.Error(apperr.Internal(err, "failed to import employees"))
+		}
+		return
+	}
+
+	warnReindex(c, ctl.search.Refresh(ctx, imported...))
+
+	report["message"] = "employees imported"
+	report["imported"] = len(rows)
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"manajemen-karyawan-api/apperr"
+)
+
+// postImport uploads csv as the import file.
+func postImport(t *testing.T, r http.Handler, csv string) testResponse {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "employees.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(csv))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/employee/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return testResponse{w, t}
+}
+
+func TestImportEmployeesLengthLimits(t *testing.T) {
+	r, store := newEmployeeRouter(t)
+
+	csv := "employee_id,name,departement,address,position\n" +
+		"EMP001,Budi,IT,Jl. Merdeka 1,Staff\n" +
+		strings.Repeat("E", 51) + ",Sari,IT,,\n" +
+		"EMP003," + strings.Repeat("n", 256) + ",HR,,\n" +
+		"EMP004,Rina,HR," + strings.Repeat("a", 1001) + "," + strings.Repeat("p", 256) + "\n"
+
+	p := postImport(t, r, csv).expectProblem(http.StatusBadRequest, apperr.CodeValidationFailed)
+	want := map[string]bool{
+		"rows[3].employee_id": true,
+		"rows[4].name":        true,
+		"rows[5].address":     true,
+		"rows[5].position":    true,
+	}
+	if len(p.Errors) != len(want) {
+		t.Errorf("errors = %+v", p.Errors)
+	}
+	for _, fe := range p.Errors {
+		if !want[fe.Field] || fe.Code != "max" {
+			t.Errorf("unexpected error %+v", fe)
+		}
+	}
+
+	if ids, _ := store.Employees().EmployeeIDsInUse(context.Background()); len(ids) != 0 {
+		t.Errorf("invalid file imported %v", ids)
+	}
+
+	postImport(t, r, "employee_id,name,departement\nEMP001,Budi,IT\n").expect(http.StatusOK)
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository/memstore"
+	"manajemen-karyawan-api/service/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newEmployeeRouter(t *testing.T) (*gin.Engine, *memstore.Store) {
+	return newEmployeeRouterIn(t, time.UTC)
+}
+
+// newEmployeeRouterIn is newEmployeeRouter with loc as the business timezone.
+func newEmployeeRouterIn(t *testing.T, loc *time.Location) (*gin.Engine, *memstore.Store) {
+	store := memstore.New(loc)
+	seedDepartement(t, store, "dep-it", "IT", "08:00:00", "17:00:00")
+	seedDepartement(t, store, "dep-hr", "HR", "09:00:00", "18:00:00")
+
+	ctl := NewEmployeeController(store, search.NewIndex(store), "password123", loc)
+	r := newTestRouter()
+	employee := r.Group("/api/employee")
+	employee.POST("/GetData", ctl.GetAllEmployees)
+	employee.GET("/:id", ctl.GetEmployeeByID)
+	employee.POST("", ctl.CreateEmployee)
+	employee.POST("/import", ctl.ImportEmployees)
+	employee.PUT("/:id", ctl.UpdateEmployee)
+	employee.DELETE("/:id", ctl.DeleteEmployee)
+	employee.POST("/:id/restore", ctl.RestoreEmployee)
+	employee.POST("/:id/events", ctl.CreateEmployeeEvent)
+	employees := r.Group("/api/v2/employees")
+	employees.GET("", ctl.ListEmployees)
+	employees.POST("", ctl.CreateEmployeeV2)
+	employees.GET("/:id", ctl.GetEmployeeByID)
+	employees.PATCH("/:id", ctl.UpdateEmployee)
+	employees.DELETE("/:id", ctl.DeleteEmployeeV2)
+	return r, store
+}
+
+func TestEmployeeCRUD(t *testing.T) {
+	r, store := newEmployeeRouter(t)
+	ctx := context.Background()
+
+	var createdResp struct{ ID string }
+	do(t, r, http.MethodPost, "/api/employee", EmployeePayload{
+		EmployeeID:    strPtr("EMP100"),
+		Name:          strPtr("Budi"),
+		DepartementID: strPtr("dep-it"),
+		Position:      strPtr("Staff"),
+	}).expect(http.StatusOK).decode(&createdResp)
+	id := createdResp.ID
+
+	var e model.Employee
+	w := do(t, r, http.MethodGet, "/api/employee/"+id, nil).expect(http.StatusOK)
+	w.decode(&e)
+	if e.EmployeeID != "EMP100" || e.DepartementName != "IT" || e.Version != 1 {
+		t.Errorf("created employee = %+v", e)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf("ETag = %q", etag)
+	}
+	if p, _ := store.Employees().GetByEmployeeID(ctx, "EMP100"); p.Password == "" || p.Password == "password123" {
+		t.Errorf("password was not hashed: %q", p.Password)
+	}
+	if events, _ := store.Employees().ListEvents(ctx, id); len(events) != 1 || events[0].EventType != model.EmployeeEventHire {
+		t.Errorf("events after create = %+v", events)
+	}
+
+	// A transfer bumps the version, records an event and is audited
+	var updated struct{ Version int }
+	do(t, r, http.MethodPut, "/api/employee/"+id, EmployeePayload{DepartementID: strPtr("dep-hr")},
+		"If-Match", `"1"`).expect(http.StatusOK).decode(&updated)
+	if updated.Version != 2 {
+		t.Errorf("version after update = %d", updated.Version)
+	}
+	if events, _ := store.Employees().ListEvents(ctx, id); len(events) != 2 || events[1].EventType != model.EmployeeEventTransfer {
+		t.Errorf("events after transfer = %+v", events)
+	}
+	do(t, r, http.MethodPut, "/api/employee/"+id, EmployeePayload{Name: strPtr("Budi S")},
+		"If-Match", `"1"`).expectProblem(http.StatusPreconditionFailed, apperr.CodePreconditionFailed)
+
+	do(t, r, http.MethodDelete, "/api/employee/"+id, nil, "If-Match", `"1"`).
+		expectProblem(http.StatusPreconditionFailed, apperr.CodePreconditionFailed)
+	do(t, r, http.MethodDelete, "/api/employee/"+id, nil, "If-Match", `"2"`).expect(http.StatusOK)
+	do(t, r, http.MethodGet, "/api/employee/"+id, nil).expectProblem(http.StatusNotFound, apperr.CodeNotFound)
+	do(t, r, http.MethodDelete, "/api/employee/"+id, nil).expectProblem(http.StatusNotFound, apperr.CodeNotFound)
+
+	// The code of a deleted employee stays reserved until it is restored
+	do(t, r, http.MethodPost, "/api/employee", EmployeePayload{
+		EmployeeID:    strPtr("EMP100"),
+		Name:          strPtr("Other"),
+		DepartementID: strPtr("dep-it"),
+	}).expectProblem(http.StatusConflict, apperr.CodeConflict)
+	do(t, r, http.MethodPost, "/api/employee/"+id+"/restore", nil).expect(http.StatusOK)
+	do(t, r, http.MethodGet, "/api/employee/"+id, nil).expect(http.StatusOK).decode(&e)
+	if e.DepartementID != "dep-hr" || e.Version != 4 {
+		t.Errorf("restored employee = %+v", e)
+	}
+
+	var actions []string
+	for _, entry := range store.AuditLogs() {
+		if entry.EntityID == id {
+			actions = append(actions, entry.Action)
+		}
+	}
+	want := []string{model.AuditActionCreate, model.AuditActionUpdate, model.AuditActionDelete, model.AuditActionRestore}
+	if len(actions) != len(want) {
+		t.Fatalf("audit actions = %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("audit actions = %v, want %v", actions, want)
+		}
+	}
+}
+
+func TestCreateEmployeeValidation(t *testing.T) {
+	r, _ := newEmployeeRouter(t)
+
+	tests := []struct {
+		name    string
+		payload EmployeePayload
+		field   string
+	}{
+		{
+			name:    "missing employee ID",
+			payload: EmployeePayload{Name: strPtr("Budi"), DepartementID: strPtr("dep-it")},
+			field:   "employeeID",
+		},
+		{
+			name:    "blank name",
+			payload: EmployeePayload{EmployeeID: strPtr("EMP1"), Name: strPtr("  "), DepartementID: strPtr("dep-it")},
+			field:   "name",
+		},
+		{
+			name:    "unknown departement",
+			payload: EmployeePayload{EmployeeID: strPtr("EMP1"), Name: strPtr("Budi"), DepartementID: strPtr("dep-x")},
+			field:   "departmentID",
+		},
+		{
+			name:    "bad hire date",
+			payload: EmployeePayload{EmployeeID: strPtr("EMP1"), Name: strPtr("Budi"), DepartementID: strPtr("dep-it"), HireDate: strPtr("01-02-2024")},
+			field:   "hireDate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := do(t, r, http.MethodPost, "/api/employee", tt.payload).
+				expectProblem(http.StatusBadRequest, apperr.CodeValidationFailed)
+			if len(p.Errors) != 1 || p.Errors[0].Field != tt.field {
+				t.Errorf("errors = %+v, want one on %s", p.Errors, tt.field)
+			}
+			if p.Error == "" {
+				t.Error("v1 problems repeat the detail in error")
+			}
+		})
+	}
+}
+
+func TestEmployeeV2(t *testing.T) {
+	r, store := newEmployeeRouter(t)
+	seedEmployee(t, store, "EMP001", "dep-it")
+
+	w := do(t, r, http.MethodPost, "/api/v2/employees", EmployeePayload{
+		EmployeeID:    strPtr("EMP002"),
+		Name:          strPtr("Sari"),
+		DepartementID: strPtr("dep-hr"),
+		HireDate:      strPtr("2024-01-15"),
+	}).expect(http.StatusCreated)
+	var e model.Employee
+	w.decode(&e)
+	if loc := w.Header().Get("Location"); loc != "/api/v2/employees/"+e.ID {
+		t.Errorf("Location = %q", loc)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf("ETag = %q", etag)
+	}
+
+	var list struct {
+		Data []model.Employee
+		Meta map[string]interface{}
+	}
+	do(t, r, http.MethodGet, "/api/v2/employees?page=1&per_page=1", nil).expect(http.StatusOK).decode(&list)
+	if len(list.Data) != 1 || list.Data[0].EmployeeID != "EMP001" || list.Meta["total"] != float64(2) {
+		t.Errorf("list = %+v", list)
+	}
+
+	do(t, r, http.MethodPatch, "/api/v2/employees/"+e.ID, EmployeePayload{Address: strPtr("Jl. Merdeka 1")},
+		"If-Match", `W/"1"`).expect(http.StatusOK)
+	do(t, r, http.MethodPatch, "/api/v2/employees/"+e.ID, EmployeePayload{Address: strPtr("x")},
+		"If-Match", "1").expectProblem(http.StatusBadRequest, apperr.CodeInvalidRequest)
+
+	do(t, r, http.MethodDelete, "/api/v2/employees/"+e.ID, nil).expect(http.StatusNoContent)
+	p := do(t, r, http.MethodGet, "/api/v2/employees/"+e.ID, nil).expectProblem(http.StatusNotFound, apperr.CodeNotFound)
+	if p.Error != "" {
+		t.Errorf("v2 problems have no error member: %+v", p)
+	}
+}
+
+func TestEmployeeEventsDatedInBusinessTimezone(t *testing.T) {
+	// A zone whose date differs from the UTC one right now
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	if time.Now().UTC().Hour() < 10 {
+		loc = time.FixedZone("UTC-12", -12*60*60)
+	}
+	r, store := newEmployeeRouterIn(t, loc)
+	ctx := context.Background()
+
+	before := time.Now().In(loc).Format("2006-01-02")
+	var created struct{ ID string }
+	do(t, r, http.MethodPost, "/api/employee", EmployeePayload{
+		EmployeeID:    strPtr("EMP200"),
+		Name:          strPtr("Rina"),
+		DepartementID: strPtr("dep-it"),
+	}).expect(http.StatusOK).decode(&created)
+	do(t, r, http.MethodPut, "/api/employee/"+created.ID, EmployeePayload{DepartementID: strPtr("dep-hr")}).
+		expect(http.StatusOK)
+	after := time.Now().In(loc).Format("2006-01-02")
+
+	events, err := store.Employees().ListEvents(ctx, created.ID)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
+	for _, ev := range events {
+		if day := ev.EffectiveDate.Format("2006-01-02"); day != before && day != after {
+			t.Errorf("%s effective %s, want %s", ev.EventType, day, after)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"manajemen-karyawan-api/buildinfo"
+	"manajemen-karyawan-api/migrations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds each dependency check of /readyz so a hung
+// database fails the probe instead of blocking it.
+const readinessTimeout = 2 * time.Second
+
+type HealthController struct {
+	db       *sql.DB
+	migrator *migrations.Migrator
+}
+
+func NewHealthController(db *sql.DB, migrator *migrations.Migrator) *HealthController {
+	return &HealthController{db: db, migrator: migrator}
+}
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Selalu 200 selama proses berjalan. Tidak memeriksa database.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (ctl *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description 200 bila database bisa di-ping dan semua migration sudah diterapkan, 503 bila tidak.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /readyz [get]
+func (ctl *HealthController) Readyz(c *gin.Context) {
+	checks := gin.H{}
+	ready := true
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := ctl.db.PingContext(ctx); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Readiness database error", "error", err)
+		checks["database"] = "unreachable"
+		ready = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if ready {
+		pending, err := ctl.migrator.Pending(ctx)
+		switch {
+		case err != nil:
+			slog.ErrorContext(c.Request.Context(), "Readiness migration error", "error", err)
+			checks["migrations"] = "unknown"
+			ready = false
+		case pending > 0:
+			checks["migrations"] = gin.H{"pending": pending}
+			ready = false
+		default:
+			checks["migrations"] = "ok"
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
+
+// Version godoc
+// @Summary Informasi build
+// @Description Versi, commit git, waktu build dan versi Go dari binary yang berjalan.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} buildinfo.Info
+// @Router /version [get]
+func (ctl *HealthController) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
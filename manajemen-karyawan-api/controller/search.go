@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/service/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSearchQueryLength = 200
+
+type SearchController struct {
+	index *search.Index
+}
+
+func NewSearchController(index *search.Index) *SearchController {
+	return &SearchController{index: index}
+}
+
+// Search godoc
+// @Summary Cari karyawan
+// @Description Pencarian teks bebas di employee ID, nama, alamat, departemen dan jabatan karyawan aktif. Hasil diurutkan dari yang paling relevan dan tetap ditemukan walaupun ada salah ketik kecil. Setiap kata di q harus cocok. Autentikasi via JWT cookie.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Kata kunci"
+// @Param limit query int false "Jumlah hasil maksimal (default 20, maksimal 100)"
+// @Success 200 {array} search.Hit
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Router /api/search [get]
+// @Router /api/v2/search [get]
+func (ctl *SearchController) Search(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.Error(apperr.Invalid("q", "required", "q is required"))
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchQueryLength {
+		c.Error(apperr.Invalid("q", "max", "q must be at most 200 characters"))
+		return
+	}
+
+	limit := search.DefaultLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > search.MaxLimit {
+			c.Error(apperr.Invalid("limit", "range", "limit must be between 1 and 100"))
+			return
+		}
+		limit = n
+	}
+
+	hits, total := ctl.index.Search(q, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"data": hits,
+		"meta": gin.H{"q": q, "limit": limit, "total": total},
+	})
+}
+
+// warnReindex logs a failed search index refresh. The write it followed is
+// already committed, so the request still succeeds and the periodic rebuild
+// catches the index up.
+func warnReindex(c *gin.Context, err error) {
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Search index refresh failed", "error", err)
+	}
+}
@@ -0,0 +1,342 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryPagination reads optional page/per_page query parameters.
+func queryPagination(c *gin.Context) (*int, *int, utils.Pagination) {
+	var page, perPage *int
+	if v, err := strconv.Atoi(c.Query("page")); err == nil {
+		page = &v
+	}
+	if v, err := strconv.Atoi(c.Query("per_page")); err == nil {
+		perPage = &v
+	}
+	return page, perPage, utils.BuildPagination(page, perPage)
+}
+
+// GetEmployeeTrash godoc
+// @Summary List karyawan yang sudah dihapus
+// @Description Menampilkan karyawan yang di-soft delete, diurutkan dari yang terakhir dihapus. Autentikasi via JWT cookie.
+// @Tags Employee
+// @Produce json
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Success 200 {array} model.Employee
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/employee/trash [get]
+// @Router /api/v2/employees/trash [get]
+func (ctl *EmployeeController) GetEmployeeTrash(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	page, perPage, pagination := queryPagination(c)
+
+	result, total, err := ctl.store.Employees().ListDeleted(c.Request.Context(), pagination)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch deleted employees"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+		"meta": utils.BuildMeta(utils.MetaParams{Page: page, PerPage: perPage, Total: total}),
+	})
+}
+
+// RestoreEmployee godoc
+// @Summary Pulihkan karyawan yang dihapus
+// @Description Membatalkan soft delete karyawan. Ditolak (409) jika departemennya sudah dihapus. Employee ID karyawan yang dihapus tidak bisa dipakai karyawan baru, jadi tidak mungkin bentrok saat dipulihkan. Autentikasi via JWT cookie.
+// @Tags Employee
+// @Produce json
+// @Param id path string true "ID Karyawan"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/employee/{id}/restore [post]
+// @Router /api/v2/employees/{id}/restore [post]
+func (ctl *EmployeeController) RestoreEmployee(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := c.Param("id")
+	userID := c.GetString("employee_id")
+	ctx := c.Request.Context()
+	emp, err := ctl.store.Employees().GetDeleted(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("deleted employee not found"))
+		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch deleted employee"))
+		return
+	}
+
+	// The employee ID cannot clash: codes stay reserved while deleted, see
+	// EmployeeIDTaken
+	deptActive, err := ctl.store.Departements().Exists(ctx, emp.DepartementID)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to check restore conflicts"))
+		return
+	}
+	if !deptActive {
+		c.Error(apperr.Conflict("departement of this employee is deleted, restore it first"))
+		return
+	}
+
+	err = restoreRow(ctl.store, c, "employee", id, func(tx repository.Store, now time.Time) error {
+		return tx.Employees().Restore(ctx, id, userID, now)
+	}, emp.DeletedAt)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to restore employee"))
+		return
+	}
+	warnReindex(c, ctl.search.Refresh(ctx, id))
+
+	c.JSON(http.StatusOK, gin.H{"message": "employee restored"})
+}
+
+// GetDepartementTrash godoc
+// @Summary List departemen yang sudah dihapus
+// @Description Menampilkan departemen yang di-soft delete, diurutkan dari yang terakhir dihapus. Autentikasi via JWT cookie.
+// @Tags Departement
+// @Produce json
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Success 200 {array} model.Departement
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/departement/trash [get]
+// @Router /api/v2/departements/trash [get]
+func (ctl *DepartementController) GetDepartementTrash(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	page, perPage, pagination := queryPagination(c)
+
+	result, total, err := ctl.store.Departements().ListDeleted(c.Request.Context(), pagination)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch deleted departements"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": result,
+		"meta": utils.BuildMeta(utils.MetaParams{Page: page, PerPage: perPage, Total: total}),
+	})
+}
+
+// RestoreDepartement godoc
+// @Summary Pulihkan departemen yang dihapus
+// @Description Membatalkan soft delete departemen. Ditolak (409) jika parent-nya masih terhapus atau sudah ada departemen aktif dengan nama yang sama. Autentikasi via JWT cookie.
+// @Tags Departement
+// @Produce json
+// @Param id path string true "ID Departemen"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/departement/{id}/restore [post]
+// @Router /api/v2/departements/{id}/restore [post]
+func (ctl *DepartementController) RestoreDepartement(c *gin.Context) {
+	_, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := c.Param("id")
+	userID := c.GetString("employee_id")
+	ctx := c.Request.Context()
+	d, err := ctl.store.Departements().GetDeleted(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(apperr.NotFound("deleted departement not found"))
+		return
+	} else if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch deleted departement"))
+		return
+	}
+
+	if parentID := derefString(d.ParentID); parentID != "" {
+		parentActive, err := ctl.store.Departements().Exists(ctx, parentID)
+		if err != nil {
+			c.Error(apperr.Internal(err, "failed to check restore conflicts"))
+			return
+		}
+		if !parentActive {
+			c.Error(apperr.Conflict("parent departement is deleted, restore it first"))
+			return
+		}
+	}
+
+	if err := checkNameFree(ctx, ctl.store, d.DepartementName, id); err != nil {
+		c.Error(apperr.Internal(err, "failed to check restore conflicts"))
+		return
+	}
+
+	err = restoreRow(ctl.store, c, "departement", id, func(tx repository.Store, now time.Time) error {
+		return tx.Departements().Restore(ctx, id, userID, now)
+	}, d.DeletedAt)
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to restore departement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "departement restored"})
+}
+
+// restoreRow runs restore and records it in the audit trail within one
+// transaction.
+func restoreRow(store repository.Store, c *gin.Context, entity string, id string, restore func(tx repository.Store, now time.Time) error, deletedAt *time.Time) error {
+	return store.WithTx(c.Request.Context(), func(tx repository.Store) error {
+		if err := restore(tx, time.Now()); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, entity, id, model.AuditActionRestore, map[string]model.AuditChange{
+			"deleted_at": {Old: repository.NormalizeAuditValue(deletedAt), New: nil},
+		})
+	})
+}
+
+type PurgeRequest struct {
+	Entity        string `json:"entity"`
+	OlderThanDays *int   `json:"olderThanDays,omitempty"`
+	DryRun        bool   `json:"dryRun"`
+}
+
+// PurgeDeleted godoc
+// @Summary Hapus permanen data yang sudah di-soft delete
+// @Description Menghapus permanen data (employee, departement, attendance, atau semua jika entity kosong) yang sudah dihapus lebih lama dari masa retensi. olderThanDays tidak boleh lebih kecil dari retensi di konfigurasi. Departemen yang masih direferensikan dilewati. Departemen yang dipimpin karyawan yang di-purge dikosongkan head-nya (versi naik dan tercatat di audit trail). Hanya untuk admin.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param payload body PurgeRequest true "Aturan purge"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 403 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/admin/purge [post]
+// @Router /api/v2/admin/purge [post]
+func (ctl *AdminController) PurgeDeleted(c *gin.Context) {
+	var req PurgeRequest
+	if err := utils.BindJSONStrict(c, &req); err != nil {
+		return
+	}
+
+	retention := map[string]int{
+		"attendance":  ctl.retention.AttendanceDays,
+		"employee":    ctl.retention.EmployeeDays,
+		"departement": ctl.retention.DepartementDays,
+	}
+	// Dependants first, so purged employees free their departements
+	order := []string{"attendance", "employee", "departement"}
+
+	entity := strings.ToLower(req.Entity)
+	if entity != "" {
+		if _, ok := retention[entity]; !ok {
+			c.Error(apperr.Invalid("entity", "oneof", "entity must be employee, departement or attendance"))
+			return
+		}
+		order = []string{entity}
+	}
+
+	now := time.Now()
+	cutoffs := map[string]time.Time{}
+	for _, name := range order {
+		days := retention[name]
+		if req.OlderThanDays != nil {
+			if *req.OlderThanDays < days {
+				c.Error(apperr.Invalid("olderThanDays", "min", fmt.Sprintf("olderThanDays for %s must be at least %d", name, days)))
+				return
+			}
+			days = *req.OlderThanDays
+		}
+		cutoffs[name] = now.AddDate(0, 0, -days)
+	}
+
+	ctx := c.Request.Context()
+	purged := map[string]int64{}
+	var failed string
+	err := ctl.store.WithTx(ctx, func(tx repository.Store) error {
+		purgers := map[string]func(context.Context, time.Time) (map[string]int64, error){
+			"attendance":  tx.Attendance().Purge,
+			"employee":    tx.Employees().Purge,
+			"departement": tx.Departements().Purge,
+		}
+
+		// Departements lose purged heads through a versioned, audited update
+		if cutoff, ok := cutoffs["employee"]; ok {
+			heads, err := tx.Departements().ClearDeletedHeads(ctx, cutoff, c.GetString("employee_id"), now)
+			if err != nil {
+				failed = "employee"
+				return err
+			}
+			for id, head := range heads {
+				changes := repository.DiffValues(map[string]interface{}{"head_employee_id": head}, map[string]interface{}{"head_employee_id": nil})
+				if err := recordUpdate(tx, c, "departement", id, changes); err != nil {
+					failed = "employee"
+					return err
+				}
+			}
+		}
+
+		for _, name := range order {
+			counts, err := purgers[name](ctx, cutoffs[name])
+			if err != nil {
+				failed = name
+				return err
+			}
+			for key, n := range counts {
+				purged[key] += n
+			}
+		}
+
+		if req.DryRun {
+			return errPurgeDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPurgeDryRun) {
+		if failed != "" {
+			c.Error(apperr.Internal(err, fmt.Sprintf("failed to purge %s", failed)))
+		} else {
+			c.Error(apperr.Internal(err, "failed to purge deleted data"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun": req.DryRun,
+		"purged": purged,
+	})
+}
+
+// errPurgeDryRun rolls back a dry-run purge once the counts are known.
+var errPurgeDryRun = errors.New("purge dry run")
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository/memstore"
+)
+
+func TestPurgeClearsDepartementHead(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New(nil)
+	seedDepartement(t, store, "dep-it", "IT", "08:00:00", "17:00:00")
+	head := seedEmployee(t, store, "EMP001", "dep-it")
+	if _, _, err := store.Departements().Update(ctx, "dep-it", map[string]interface{}{"head_employee_id": head}, 0, testActor, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Employees().SoftDelete(ctx, head, 0, testActor, time.Now().AddDate(-2, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctl := NewAdminController(store, config.RetentionConfig{EmployeeDays: 365}, time.UTC)
+	r := newTestRouter()
+	r.POST("/api/admin/purge", ctl.PurgeDeleted)
+
+	// A dry run changes nothing
+	do(t, r, http.MethodPost, "/api/admin/purge", PurgeRequest{Entity: "employee", DryRun: true}).expect(http.StatusOK)
+	if d, _ := store.Departements().GetByID(ctx, "dep-it"); d.HeadEmployeeID == nil || d.Version != 2 {
+		t.Fatalf("departement after dry run = %+v", d)
+	}
+
+	var resp struct{ Purged map[string]int64 }
+	do(t, r, http.MethodPost, "/api/admin/purge", PurgeRequest{Entity: "employee"}).expect(http.StatusOK).decode(&resp)
+	if resp.Purged["employee"] != 1 {
+		t.Errorf("purged = %v", resp.Purged)
+	}
+	d, err := store.Departements().GetByID(ctx, "dep-it")
+	if err != nil || d.HeadEmployeeID != nil || d.Version != 3 {
+		t.Errorf("departement after purge = %+v, %v", d, err)
+	}
+
+	var audited []model.AuditLog
+	for _, entry := range store.AuditLogs() {
+		if entry.Entity == "departement" && entry.EntityID == "dep-it" {
+			audited = append(audited, entry)
+		}
+	}
+	if len(audited) != 1 || audited[0].Action != model.AuditActionUpdate || audited[0].Actor != testActor {
+		t.Errorf("departement audit = %+v", audited)
+	}
+}
@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"net/http"
+	"path"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers of /api/v2 that differ from their /api counterpart. Collections
+// are read with GET and query-string filters (utils.BindQueryParams),
+// resources are addressed by row ID, creates answer 201 with a Location and
+// deletes 204. Everything else reuses the /api handlers.
+
+// created answers a create with the new resource and where to find it. When
+// it could not be read back (err) the write still happened, so the client
+// gets its ID and the Location.
+func created(c *gin.Context, id string, resource interface{}, version int, err error) {
+	c.Header("Location", path.Join(c.FullPath(), id))
+	if err != nil {
+		c.JSON(http.StatusCreated, gin.H{"id": id})
+		return
+	}
+	utils.SetETag(c, version)
+	c.JSON(http.StatusCreated, resource)
+}
+
+// ListEmployees godoc
+// @Summary List karyawan aktif (v2)
+// @Description Sama seperti POST /api/employee/GetData, tetapi parameter dikirim lewat query string: page, per_page, sort (mis. -createdAt,name), cursor, with_total, dan filter sebagai field atau field.operator (mis. status.in=active,resigned&name=budi). Autentikasi via JWT cookie.
+// @Tags Employee v2
+// @Produce json
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Param sort query string false "Urutan, tanda - untuk menurun"
+// @Param cursor query string false "Kosong untuk halaman pertama, lalu meta.next_cursor"
+// @Param with_total query bool false "Hitung total pada paginasi cursor"
+// @Success 200 {array} model.Employee
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/employees [get]
+func (ctl *EmployeeController) ListEmployees(c *gin.Context) {
+	params, err := utils.BindQueryParams(c)
+	if err != nil {
+		return
+	}
+	ctl.listEmployees(c, params)
+}
+
+// CreateEmployeeV2 godoc
+// @Summary Tambah karyawan baru (v2)
+// @Description Menambahkan karyawan dan mengembalikan data yang tersimpan beserta header Location dan ETag. Autentikasi via JWT cookie.
+// @Tags Employee v2
+// @Accept json
+// @Produce json
+// @Param payload body EmployeePayload true "Data karyawan"
+// @Success 201 {object} model.Employee
+// @Header 201 {string} Location "URL karyawan baru"
+// @Header 201 {string} ETag "Versi data"
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/employees [post]
+func (ctl *EmployeeController) CreateEmployeeV2(c *gin.Context) {
+	id, ok := ctl.createEmployee(c)
+	if !ok {
+		return
+	}
+	e, err := ctl.store.Employees().GetByID(c.Request.Context(), id)
+	created(c, id, e, e.Version, err)
+}
+
+// DeleteEmployeeV2 godoc
+// @Summary Hapus karyawan (v2, soft delete)
+// @Description Sama seperti DELETE /api/employee/{id}, tanpa body pada respons. Autentikasi via JWT cookie.
+// @Tags Employee v2
+// @Param id path string true "ID Karyawan"
+// @Param If-Match header string false "ETag versi yang dihapus"
+// @Success 204
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/employees/{id} [delete]
+func (ctl *EmployeeController) DeleteEmployeeV2(c *gin.Context) {
+	if ctl.deleteEmployee(c) {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListDepartements godoc
+// @Summary List departemen aktif (v2)
+// @Description Sama seperti POST /api/departement/GetData dengan parameter lewat query string (lihat GET /api/v2/employees). Autentikasi via JWT cookie.
+// @Tags Departement v2
+// @Produce json
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Param sort query string false "Urutan, tanda - untuk menurun"
+// @Param cursor query string false "Kosong untuk halaman pertama, lalu meta.next_cursor"
+// @Param with_total query bool false "Hitung total pada paginasi cursor"
+// @Success 200 {array} model.Departement
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/departements [get]
+func (ctl *DepartementController) ListDepartements(c *gin.Context) {
+	params, err := utils.BindQueryParams(c)
+	if err != nil {
+		return
+	}
+	ctl.listDepartements(c, params)
+}
+
+// CreateDepartementV2 godoc
+// @Summary Tambah departemen baru (v2)
+// @Description Menambahkan departemen dan mengembalikan data yang tersimpan beserta header Location dan ETag. Autentikasi via JWT cookie.
+// @Tags Departement v2
+// @Accept json
+// @Produce json
+// @Param payload body DepartementPayload true "Data departemen"
+// @Success 201 {object} model.Departement
+// @Header 201 {string} Location "URL departemen baru"
+// @Header 201 {string} ETag "Versi data"
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/departements [post]
+func (ctl *DepartementController) CreateDepartementV2(c *gin.Context) {
+	id, ok := ctl.createDepartement(c)
+	if !ok {
+		return
+	}
+	d, err := ctl.store.Departements().GetByID(c.Request.Context(), id)
+	created(c, id, d, d.Version, err)
+}
+
+// DeleteDepartementV2 godoc
+// @Summary Hapus departemen (v2, soft delete)
+// @Description Sama seperti DELETE /api/departement/{id}, termasuk reassign_to, tanpa body pada respons. Autentikasi via JWT cookie.
+// @Tags Departement v2
+// @Param id path string true "ID Departemen"
+// @Param If-Match header string false "ETag versi yang dihapus"
+// @Param reassign_to query string false "ID departemen tujuan untuk karyawan yang tersisa"
+// @Success 204
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 409 {object} apperr.Problem
+// @Failure 412 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/departements/{id} [delete]
+func (ctl *DepartementController) DeleteDepartementV2(c *gin.Context) {
+	if _, ok := ctl.deleteDepartement(c); ok {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListAttendance godoc
+// @Summary List semua log absensi (v2)
+// @Description Sama seperti POST /api/attendance/GetData dengan parameter lewat query string (lihat GET /api/v2/employees). Autentikasi via JWT cookie.
+// @Tags Attendance v2
+// @Produce json
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Param sort query string false "Urutan, tanda - untuk menurun"
+// @Param cursor query string false "Kosong untuk halaman pertama, lalu meta.next_cursor"
+// @Param with_total query bool false "Hitung total pada paginasi cursor"
+// @Success 200 {array} model.AttendanceItem
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/attendance [get]
+func (ctl *AttendanceController) ListAttendance(c *gin.Context) {
+	params, err := utils.BindQueryParams(c)
+	if err != nil {
+		return
+	}
+	ctl.listAttendanceLogs(c, params, "")
+}
+
+// ListEmployeeAttendance godoc
+// @Summary Log absensi satu karyawan (v2)
+// @Description Log absensi karyawan berdasarkan ID karyawan (bukan employee ID), dengan parameter lewat query string. Autentikasi via JWT cookie.
+// @Tags Attendance v2
+// @Produce json
+// @Param id path string true "ID Karyawan"
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Param sort query string false "Urutan, tanda - untuk menurun"
+// @Param cursor query string false "Kosong untuk halaman pertama, lalu meta.next_cursor"
+// @Param with_total query bool false "Hitung total pada paginasi cursor"
+// @Success 200 {array} model.AttendanceItem
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/employees/{id}/attendance [get]
+func (ctl *AttendanceController) ListEmployeeAttendance(c *gin.Context) {
+	params, err := utils.BindQueryParams(c)
+	if err != nil {
+		return
+	}
+
+	logs, page, err := ctl.attendance.EmployeeLogs(c.Request.Context(), params, c.Param("id"))
+	if err != nil {
+		c.Error(apperr.Internal(err, "failed to fetch attendance logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": logs,
+		"meta": page.Meta(params),
+	})
+}
+
+// ListMyAttendance godoc
+// @Summary Log absensi karyawan yang login (v2)
+// @Description Sama seperti POST /api/attendance/logs dengan parameter lewat query string. Autentikasi via JWT cookie.
+// @Tags Attendance v2
+// @Produce json
+// @Param page query int false "Halaman"
+// @Param per_page query int false "Jumlah per halaman"
+// @Param sort query string false "Urutan, tanda - untuk menurun"
+// @Param cursor query string false "Kosong untuk halaman pertama, lalu meta.next_cursor"
+// @Param with_total query bool false "Hitung total pada paginasi cursor"
+// @Success 200 {array} model.AttendanceItem
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/me/attendance [get]
+func (ctl *AttendanceController) ListMyAttendance(c *gin.Context) {
+	employeeID, exists := c.Get("employee_id")
+	if !exists {
+		c.Error(apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	params, err := utils.BindQueryParams(c)
+	if err != nil {
+		return
+	}
+	id, _ := employeeID.(string)
+	ctl.listAttendanceLogs(c, params, id)
+}
+
+// GetAttendanceSummaryV2 godoc
+// @Summary Rekap absensi per departemen (v2)
+// @Description Sama seperti POST /api/attendance/summary dengan parameter lewat query string. Autentikasi via JWT cookie.
+// @Tags Attendance v2
+// @Produce json
+// @Param dateFrom query string true "Tanggal awal (YYYY-MM-DD)"
+// @Param dateTo query string true "Tanggal akhir (YYYY-MM-DD)"
+// @Param departementID query string false "Batasi ke satu departemen beserta sub-departemennya"
+// @Success 200 {array} model.DepartementAttendanceSummary
+// @Failure 400 {object} apperr.Problem
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /api/v2/attendance/summary [get]
+func (ctl *AttendanceController) GetAttendanceSummaryV2(c *gin.Context) {
+	ctl.attendanceSummary(c, AttendanceSummaryRequest{
+		DateFrom:      c.Query("dateFrom"),
+		DateTo:        c.Query("dateTo"),
+		DepartementID: c.Query("departementID"),
+	})
+}
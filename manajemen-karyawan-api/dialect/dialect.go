@@ -0,0 +1,184 @@
+// Package dialect captures the SQL differences between the supported
+// databases so that repositories and migrations can share one set of queries
+// written with MySQL-style "?" placeholders.
+package dialect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"manajemen-karyawan-api/utils"
+)
+
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Parse maps a configured driver name to a Dialect.
+func Parse(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "mysql":
+		return MySQL, nil
+	case "postgres", "postgresql", "pgx":
+		return Postgres, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	}
+	return "", fmt.Errorf("unsupported database driver %q (want mysql, postgres or sqlite)", name)
+}
+
+// DriverName is the database/sql driver registered for the dialect.
+func (d Dialect) DriverName() string {
+	switch d {
+	case Postgres:
+		return "pgx"
+	case SQLite:
+		return "sqlite"
+	}
+	return "mysql"
+}
+
+// Rebind rewrites "?" placeholders into the dialect's own style.
+func (d Dialect) Rebind(query string) string {
+	if d == Postgres {
+		return utils.ReplacePlaceholders(query)
+	}
+	return query
+}
+
+// BindArgs adjusts query arguments before they reach the driver. SQLite
+// stores timestamps as text and compares them as strings, so every time is
+// normalised to UTC to keep the ordering right.
+func (d Dialect) BindArgs(args []interface{}) []interface{} {
+	if d != SQLite {
+		return args
+	}
+	bound := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			bound[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				bound[i] = v.UTC()
+			} else {
+				bound[i] = nil
+			}
+		default:
+			bound[i] = arg
+		}
+	}
+	return bound
+}
+
+// LocalDate returns an expression for the calendar date in loc of the
+// timestamp expr, which must be stored in UTC. PostgreSQL converts with the
+// zone itself; MySQL and SQLite may not have zone data, so expr is shifted by
+// the offset loc had at that instant, looked up in the offset changes of loc.
+func (d Dialect) LocalDate(expr string, loc *time.Location) string {
+	if d == Postgres && loc.String() != "Local" {
+		zone := strings.ReplaceAll(loc.String(), "'", "''")
+		return "CAST(" + expr + " AT TIME ZONE '" + zone + "' AS DATE)"
+	}
+
+	offset := d.offsetAt(expr, loc)
+	switch d {
+	case Postgres:
+		return fmt.Sprintf("CAST(%s AT TIME ZONE 'UTC' + %s * INTERVAL '1 second' AS DATE)", expr, offset)
+	case SQLite:
+		return fmt.Sprintf("date(%s, %s || ' seconds')", expr, offset)
+	}
+	return fmt.Sprintf("DATE(DATE_ADD(%s, INTERVAL %s SECOND))", expr, offset)
+}
+
+// offsetAt returns an expression for the UTC offset in seconds of loc at the
+// timestamp expr, newest change first since most rows are recent.
+func (d Dialect) offsetAt(expr string, loc *time.Location) string {
+	changes := offsetChanges(loc)
+	if len(changes) == 1 {
+		return strconv.Itoa(changes[0].offset)
+	}
+
+	var b strings.Builder
+	b.WriteString("(CASE")
+	for i := len(changes) - 1; i > 0; i-- {
+		at := changes[i].at.Format("2006-01-02 15:04:05")
+		if d == Postgres {
+			at += "+00"
+		}
+		fmt.Fprintf(&b, " WHEN %s >= '%s' THEN %d", expr, at, changes[i].offset)
+	}
+	fmt.Fprintf(&b, " ELSE %d END)", changes[0].offset)
+	return b.String()
+}
+
+// offsetChange is the UTC offset of a zone from at on.
+type offsetChange struct {
+	at     time.Time
+	offset int
+}
+
+// Offset changes are looked up from offsetRangeStart until offsetRangeYears
+// from now; timestamps outside that range use the nearest offset.
+var offsetRangeStart = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const offsetRangeYears = 10
+
+var offsetCache sync.Map // *time.Location -> []offsetChange
+
+// offsetChanges lists the offsets of loc from offsetRangeStart until
+// offsetRangeYears from now. The first entry is the offset at the start.
+func offsetChanges(loc *time.Location) []offsetChange {
+	if cached, ok := offsetCache.Load(loc); ok {
+		return cached.([]offsetChange)
+	}
+
+	offsetOf := func(t time.Time) int {
+		_, offset := t.In(loc).Zone()
+		return offset
+	}
+	changes := []offsetChange{{offsetRangeStart, offsetOf(offsetRangeStart)}}
+	end := time.Now().AddDate(offsetRangeYears, 0, 0)
+	// Zones change their offset at most a few times a year, so stepping a
+	// day at a time and bisecting each step that changed finds every one.
+	for t := offsetRangeStart; t.Before(end); t = t.Add(24 * time.Hour) {
+		next := t.Add(24 * time.Hour)
+		if offsetOf(next) == changes[len(changes)-1].offset {
+			continue
+		}
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+			if offsetOf(mid) == offsetOf(lo) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		changes = append(changes, offsetChange{hi, offsetOf(hi)})
+	}
+
+	offsetCache.Store(loc, changes)
+	return changes
+}
+
+// ForUpdate returns the row locking clause for SELECT statements. SQLite has
+// none; its write transactions already lock the whole database.
+func (d Dialect) ForUpdate() string {
+	if d == SQLite {
+		return ""
+	}
+	return "FOR UPDATE"
+}
+
+// TransactionalDDL reports whether schema changes can be rolled back.
+func (d Dialect) TransactionalDDL() bool {
+	return d != MySQL
+}
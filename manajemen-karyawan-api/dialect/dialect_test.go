@@ -0,0 +1,74 @@
+package dialect
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLocalDate(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:?_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE t (ts TIMESTAMP NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		at   string
+		want string
+	}{
+		{"EST evening", newYork, "2024-01-15T04:30:00Z", "2024-01-14"},
+		{"EST morning", newYork, "2024-01-15T05:30:00Z", "2024-01-15"},
+		{"EDT evening", newYork, "2024-07-15T03:30:00Z", "2024-07-14"},
+		{"EDT morning", newYork, "2024-07-15T04:30:00Z", "2024-07-15"},
+		{"just before springing forward", newYork, "2024-03-10T06:59:59Z", "2024-03-10"},
+		{"midnight after springing forward", newYork, "2024-03-11T04:00:00Z", "2024-03-11"},
+		{"midnight after falling back", newYork, "2024-11-04T05:00:00Z", "2024-11-04"},
+		{"hour before midnight after falling back", newYork, "2024-11-04T04:00:00Z", "2024-11-03"},
+		{"fixed offset", jakarta, "2024-03-10T17:00:00Z", "2024-03-11"},
+		{"fractional seconds", jakarta, "2024-03-10T16:59:59.5Z", "2024-03-10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := time.Parse(time.RFC3339Nano, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.Exec("DELETE FROM t"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.Exec("INSERT INTO t (ts) VALUES (?)", SQLite.BindArgs([]interface{}{at})...); err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			if err := db.QueryRow("SELECT " + SQLite.LocalDate("ts", tt.loc) + " FROM t").Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("LocalDate = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if expr := MySQL.LocalDate("ts", jakarta); strings.Contains(expr, "CASE") {
+		t.Errorf("zone without offset changes since 2000 got %s", expr)
+	}
+}
@@ -0,0 +1,154 @@
+// Package logger configures the process-wide log/slog logger: JSON or text
+// output, a minimum level, request-scoped attributes taken from the context,
+// and redaction of sensitive values.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively as substrings of attribute
+// and map keys, so "password", "newPassword" and "jwt_secret" all match.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie", "dsn"}
+
+// ParseLevel maps debug, info, warn or error to a slog level.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("log level %q must be debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// New returns a logger writing to w in format "json" or "text".
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("log format %q must be json or text", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// IsSensitive reports whether values under key must not be logged.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if v, ok := Redact(a.Value.Any()).(map[string]interface{}); ok {
+			return slog.Any(a.Key, v)
+		}
+	}
+	return a
+}
+
+// Redact returns a copy of v with the values of sensitive keys replaced, for
+// maps decoded from JSON. Other values are returned unchanged.
+func Redact(v interface{}) interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			if IsSensitive(k) {
+				out[k] = redacted
+			} else {
+				out[k] = Redact(val)
+			}
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			if IsSensitive(k) {
+				out[k] = redacted
+			} else {
+				out[k] = val
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(m))
+		for i, val := range m {
+			out[i] = Redact(val)
+		}
+		return out
+	}
+	return v
+}
+
+// RequestInfo identifies the request a log record belongs to. The request ID
+// middleware stores it in the request context and the auth middleware fills
+// in the user once the token is verified.
+type RequestInfo struct {
+	RequestID string
+	UserID    string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying info.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the request info of ctx, or nil outside a request.
+func RequestInfoFrom(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+// contextHandler adds request_id, user_id and, when the request is traced,
+// trace_id and span_id to records logged with a request context, e.g.
+// slog.ErrorContext(c.Request.Context(), ...).
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if info := RequestInfoFrom(ctx); info != nil {
+		r.AddAttrs(slog.String("request_id", info.RequestID))
+		if info.UserID != "" {
+			r.AddAttrs(slog.String("user_id", info.UserID))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -2,10 +2,10 @@ package main
 
 import (
 	"log"
-	"manajemen-karyawan-api/config"
-	"manajemen-karyawan-api/routes"
+	"os"
+
+	"manajemen-karyawan-api/cli"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	_ "manajemen-karyawan-api/docs"
@@ -35,28 +35,8 @@ func main() {
 		log.Println("No .env file found, falling back to system environment variables")
 	}
 
-	// ✅ Load all configuration from environment
-	config.InitConfig()
-
-	// ✅ Initialize database connection
-	config.InitDB()
-	defer config.DB.Close()
-
-	// ✅ Initialize Gin router
-	r := gin.Default()
-
-	// ✅ Register all routes
-	routes.RegisterRoutes(r)
-
-	// ✅ Get port from environment or use default
-	port := config.AppPort
-	if port == "" {
-		port = "8080"
-	}
-
-	// ✅ Start the server
-	log.Printf("Server is running on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// ✅ Without arguments the binary starts the API server (`serve`)
+	if err := cli.Run(os.Args[1:]); err != nil {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+
+	"manajemen-karyawan-api/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorMiddleware renders the last error a handler or middleware attached
+// with c.Error as an application/problem+json response, unless a response
+// was already written. Codes decide the status (see apperr.Code); errors
+// without one become a 500, and the cause of every server error is logged
+// since the client only sees the detail.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		WriteProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// WriteProblem aborts the request with err rendered as a problem document.
+func WriteProblem(c *gin.Context, err error) {
+	e := apperr.From(err)
+	if e.Status() >= 500 {
+		slog.ErrorContext(c.Request.Context(), e.Detail, "code", e.Code, "error", e.Err)
+	}
+
+	path := c.Request.URL.Path
+	problem := e.Problem(path)
+	problem.RequestID = c.GetString("request_id")
+	if strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/v2/") {
+		problem.Error = problem.Detail
+	}
+
+	c.Header("Content-Type", apperr.ContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// NoRoute answers unknown paths with a not_found problem instead of gin's
+// plain-text 404.
+func NoRoute(c *gin.Context) {
+	c.Error(apperr.NotFound("route not found"))
+}
+
+func NoMethod(c *gin.Context) {
+	c.Error(apperr.New(apperr.CodeMethodNotAllowed, "method not allowed"))
+}
@@ -2,12 +2,12 @@ package middleware
 
 import (
 	"errors"
-	"log"
-	"net/http"
+	"log/slog"
 	"strings"
 	"time"
 
-	"manajemen-karyawan-api/config"
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/logger"
 
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
@@ -20,29 +20,29 @@ var (
 	ErrTokenSignatureInvalid = errors.New("signature invalid")
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(secret []byte) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Read token from cookie
 		tokenString, err := c.Cookie("access_token")
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+			abortUnauthorized(c, apperr.Unauthorized("missing token"))
 			return
 		}
 
 		// Parse and validate token
-		claims, err := parseToken(tokenString, []byte(config.JWTSecret))
+		claims, err := parseToken(tokenString, secret)
 		if err != nil {
-			log.Printf("JWT error: %v", err)
+			slog.WarnContext(c.Request.Context(), "JWT rejected", "error", err)
 
 			switch err {
 			case ErrTokenExpired:
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token expired"})
+				abortUnauthorized(c, apperr.New(apperr.CodeTokenExpired, "token expired"))
 			case ErrTokenMalformed:
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "malformed token"})
+				abortUnauthorized(c, apperr.Unauthorized("malformed token"))
 			case ErrTokenSignatureInvalid, ErrTokenInvalid:
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+				abortUnauthorized(c, apperr.Unauthorized("invalid token"))
 			default:
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+				abortUnauthorized(c, apperr.Unauthorized("unauthorized"))
 			}
 			return
 		}
@@ -50,25 +50,37 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Extract id
 		id, ok := claims["id"].(string)
 		if !ok || strings.TrimSpace(id) == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "id missing in token"})
+			abortUnauthorized(c, apperr.Unauthorized("id missing in token"))
 			return
 		}
 
 		// Extract employee_id
 		employeeID, ok := claims["employee_id"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "employee_id missing in token"})
+			abortUnauthorized(c, apperr.Unauthorized("employee_id missing in token"))
 			return
 		}
 
+		// Role is optional for tokens issued before roles existed
+		role, _ := claims["role"].(string)
+
 		// Inject into context
+		if info := logger.RequestInfoFrom(c.Request.Context()); info != nil {
+			info.UserID = employeeID
+		}
 		c.Set("id", id)
 		c.Set("employee_id", employeeID)
+		c.Set("role", role)
 		c.Next()
 	}
 }
 
-func GenerateToken(id string, employeeID string, secret []byte) (string, error) {
+func abortUnauthorized(c *gin.Context, err *apperr.Error) {
+	c.Error(err)
+	c.Abort()
+}
+
+func GenerateToken(id string, employeeID string, role string, secret []byte, ttl time.Duration) (string, error) {
 	if len(secret) == 0 {
 		return "", ErrTokenMalformed
 	}
@@ -76,7 +88,8 @@ func GenerateToken(id string, employeeID string, secret []byte) (string, error)
 	claims := jwt.MapClaims{
 		"id":          id,
 		"employee_id": employeeID,
-		"exp":         time.Now().Add(24 * time.Hour).Unix(),
+		"role":        role,
+		"exp":         time.Now().Add(ttl).Unix(),
 		"iat":         time.Now().Unix(),
 	}
 
@@ -90,7 +103,14 @@ func parseToken(tokenString string, secret []byte) (jwt.MapClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return secret, nil
 	})
-	if err != nil {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return nil, ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return nil, ErrTokenSignatureInvalid
+	case err != nil:
 		return nil, ErrTokenInvalid
 	}
 
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := []byte("test-secret")
+
+	r := gin.New()
+	r.Use(ErrorMiddleware(), AuthMiddleware(secret))
+	r.GET("/api/me", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("employee_id"))
+	})
+
+	token := func(secret []byte, ttl time.Duration) string {
+		s, err := GenerateToken("id-1", "EMP001", "admin", secret, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		code   apperr.Code
+		detail string
+	}{
+		{"valid", token(secret, time.Hour), "", ""},
+		{"expired", token(secret, -time.Minute), apperr.CodeTokenExpired, "token expired"},
+		{"other secret", token([]byte("other-secret"), time.Hour), apperr.CodeUnauthenticated, "invalid token"},
+		{"not a JWT", "abc.def", apperr.CodeUnauthenticated, "malformed token"},
+		{"missing", "", apperr.CodeUnauthenticated, "missing token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tt.code == "" {
+				if w.Code != http.StatusOK || w.Body.String() != "EMP001" {
+					t.Errorf("status %d, body %s", w.Code, w.Body.String())
+				}
+				return
+			}
+			var p apperr.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusUnauthorized || p.Code != tt.code || p.Detail != tt.detail {
+				t.Errorf("status %d, problem %+v; want %s %q", w.Code, p, tt.code, tt.detail)
+			}
+		})
+	}
+}
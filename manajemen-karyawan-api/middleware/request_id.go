@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"manajemen-karyawan-api/logger"
+	"manajemen-karyawan-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware reuses the caller's X-Request-ID when present, otherwise
+// generates one, and exposes it as "request_id" in the context and response.
+// The request context also carries it for the logger.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = utils.GenerateID()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestInfo(c.Request.Context(), &logger.RequestInfo{RequestID: requestID}))
+		c.Next()
+	}
+}
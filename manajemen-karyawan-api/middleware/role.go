@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin must run after AuthMiddleware, which puts the role claim in the context.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != model.EmployeeRoleAdmin {
+			c.Error(apperr.Forbidden("admin role required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
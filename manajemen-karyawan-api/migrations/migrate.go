@@ -0,0 +1,470 @@
+// Package migrations applies the versioned database schema embedded in the
+// binary. Each version is a pair of files in sql/<dialect>/ named
+// NNNN_description.up.sql and NNNN_description.down.sql; applied versions are
+// recorded in the schema_migrations table. Every dialect carries the same
+// versions so that status output is comparable across backends.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+)
+
+//go:embed sql/mysql/*.sql sql/postgres/*.sql sql/sqlite/*.sql
+var files embed.FS
+
+const (
+	lockName = "schema_migrations"
+
+	// DefaultLockTimeout is how long a runner waits for another runner to
+	// finish before giving up.
+	DefaultLockTimeout = 60 * time.Second
+)
+
+var ErrLocked = errors.New("migrations: another runner holds the migration lock")
+
+// Migration is one numbered schema version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied and when.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type Migrator struct {
+	db          *sql.DB
+	dialect     dialect.Dialect
+	migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// New returns a Migrator for the migrations embedded in the binary for d.
+func New(db *sql.DB, d dialect.Dialect) (*Migrator, error) {
+	migrations, err := load(files, path.Join("sql", string(d)))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations, LockTimeout: DefaultLockTimeout}, nil
+}
+
+// load pairs up the up/down files in dir and orders them by version.
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migrations: unexpected file %s", name)
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migrations: %s must be named NNNN_name.%s.sql", name, direction)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrations: invalid version in %s", name)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migrations: version %d used by both %s and %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrations: version %d needs both an up and a down file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		for _, mig := range m.migrations {
+			ran, err := m.run(ctx, conn, mig, true)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			if ran {
+				applied = append(applied, mig)
+			}
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// legacyProbes detect the schema versions a database may have been set up
+// with from the README before migrations existed; each query fails unless
+// that version's tables and columns are there.
+var legacyProbes = []struct {
+	version int
+	query   string
+}{
+	{1, "SELECT id, employee_id FROM employee WHERE 1 = 0"},
+	{2, "SELECT parent_id, head_employee_id FROM departement WHERE 1 = 0"},
+	{3, "SELECT id FROM employee_event WHERE 1 = 0"},
+	{4, "SELECT role FROM employee WHERE 1 = 0"},
+	{5, "SELECT id FROM audit_log WHERE 1 = 0"},
+}
+
+// Adopt records the versions an existing database already has without
+// running them, so Up can continue from there. It only acts when no version
+// has been recorded yet, and then stamps the leading versions whose schema
+// is present. A fresh database is left alone.
+func (m *Migrator) Adopt(ctx context.Context) ([]Migration, error) {
+	var adopted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil || len(done) > 0 {
+			return err
+		}
+
+		present := 0
+		for _, probe := range legacyProbes {
+			if _, err := conn.ExecContext(ctx, probe.query); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				break
+			}
+			present = probe.version
+		}
+		adopted, err = m.stamp(ctx, conn, present)
+		return err
+	})
+	return adopted, err
+}
+
+// Baseline records every migration up to version as applied without
+// running it, for databases whose schema was created by other means.
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	if version < 1 || version > m.migrations[len(m.migrations)-1].Version {
+		return nil, fmt.Errorf("migrations: baseline version %d is not between 1 and %d", version, m.migrations[len(m.migrations)-1].Version)
+	}
+	var stamped []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) (err error) {
+		stamped, err = m.stamp(ctx, conn, version)
+		return err
+	})
+	return stamped, err
+}
+
+// stamp records the unrecorded migrations up to version as applied.
+func (m *Migrator) stamp(ctx context.Context, conn *sql.Conn, version int) ([]Migration, error) {
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var stamped []Migration
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := done[mig.Version]; ok {
+			continue
+		}
+		_, err := conn.ExecContext(ctx, m.dialect.Rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			mig.Version, mig.Name, time.Now().UTC())
+		if err != nil {
+			return stamped, err
+		}
+		stamped = append(stamped, mig)
+	}
+	return stamped, nil
+}
+
+// Down rolls back the latest steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			ran, err := m.run(ctx, conn, mig, false)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			if ran {
+				reverted = append(reverted, mig)
+			}
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// execer is satisfied by both *sql.Conn and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// run applies (up) or reverts (down) mig unless it is already in that state,
+// reporting whether anything ran. Where the dialect supports transactional
+// DDL the script and its bookkeeping commit together.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig Migration, up bool) (bool, error) {
+	// SQLite alters columns by rebuilding tables, which only works with
+	// foreign keys off; they are verified with foreign_key_check instead.
+	// The pragma is a no-op inside a transaction, hence before BeginTx.
+	if m.dialect == dialect.SQLite {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return false, err
+		}
+		defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+	}
+
+	var q execer = conn
+	if m.dialect.TransactionalDDL() {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+		defer tx.Rollback()
+		q = tx
+	}
+
+	var count int
+	if err := q.QueryRowContext(ctx, m.dialect.Rebind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), mig.Version).Scan(&count); err != nil {
+		return false, err
+	}
+	if (count > 0) == up {
+		return false, nil
+	}
+
+	script, record, args := mig.Down, "DELETE FROM schema_migrations WHERE version = ?", []interface{}{mig.Version}
+	if up {
+		script, record = mig.Up, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"
+		args = append(args, mig.Name, time.Now().UTC())
+	}
+
+	for _, stmt := range splitStatements(script) {
+		if _, err := q.ExecContext(ctx, stmt); err != nil {
+			return false, err
+		}
+	}
+	if _, err := q.ExecContext(ctx, m.dialect.Rebind(record), args...); err != nil {
+		return false, err
+	}
+
+	if m.dialect == dialect.SQLite {
+		var table string
+		err := q.QueryRowContext(ctx, "SELECT \"table\" FROM pragma_foreign_key_check").Scan(&table)
+		if err == nil {
+			return false, fmt.Errorf("foreign key violation in %s", table)
+		} else if err != sql.ErrNoRows {
+			return false, err
+		}
+	}
+
+	if tx, ok := q.(*sql.Tx); ok {
+		if err := tx.Commit(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Status lists every known migration with its applied time, if any.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := done[mig.Version]; ok {
+			at := at
+			s.AppliedAt = &at
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// Pending returns the number of migrations not yet applied.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// locked runs fn on a single connection holding the migration lock. Named
+// and advisory locks belong to a session, so the lock and every statement
+// must share the same connection.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	switch m.dialect {
+	case dialect.MySQL:
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.LockTimeout.Seconds())).Scan(&got); err != nil {
+			return nil, err
+		}
+		if !got.Valid || got.Int64 != 1 {
+			return nil, ErrLocked
+		}
+		return func() { conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName) }, nil
+
+	case dialect.Postgres:
+		deadline := time.Now().Add(m.LockTimeout)
+		for {
+			var got bool
+			if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", lockName).Scan(&got); err != nil {
+				return nil, err
+			}
+			if got {
+				return func() { conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", lockName) }, nil
+			}
+			if time.Now().After(deadline) {
+				return nil, ErrLocked
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+
+	// SQLite has no named locks; each migration runs in a write transaction
+	// that excludes other writers and re-checks the version inside it.
+	return func() {}, nil
+}
+
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		done[version] = at
+	}
+	return done, rows.Err()
+}
+
+// splitStatements splits a migration file into single statements, since
+// the drivers do not accept multiple statements per Exec. Statements end
+// with a semicolon at the end of a line.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,59 @@
+CREATE TABLE departement (
+    id VARCHAR(50) PRIMARY KEY,
+    departement_name VARCHAR(255) NOT NULL,
+    max_clock_in_time TIME NOT NULL,
+    max_clock_out_time TIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+CREATE TABLE employee (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL UNIQUE,
+    departement_id VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    password VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50),
+    FOREIGN KEY (departement_id) REFERENCES departement(id)
+);
+
+CREATE TABLE attendance (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    clock_in TIMESTAMP NULL DEFAULT NULL,
+    clock_out TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50),
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id)
+);
+
+CREATE TABLE attendance_history (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    attendance_id VARCHAR(50) NOT NULL,
+    date_attendance TIMESTAMP NOT NULL,
+    attendance_type TINYINT(1) NOT NULL COMMENT '1 = IN, 2 = OUT',
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50),
+    FOREIGN KEY (employee_id) REFERENCES employee(employee_id),
+    FOREIGN KEY (attendance_id) REFERENCES attendance(id)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE departement
+    DROP FOREIGN KEY fk_departement_head,
+    DROP FOREIGN KEY fk_departement_parent;
+
+ALTER TABLE departement
+    DROP COLUMN head_employee_id,
+    DROP COLUMN parent_id,
+    MODIFY max_clock_in_time TIME NOT NULL,
+    MODIFY max_clock_out_time TIME NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE departement
+    ADD COLUMN parent_id VARCHAR(50) NULL AFTER id,
+    ADD COLUMN head_employee_id VARCHAR(50) NULL AFTER parent_id,
+    MODIFY max_clock_in_time TIME NULL COMMENT 'NULL = ikut parent',
+    MODIFY max_clock_out_time TIME NULL COMMENT 'NULL = ikut parent',
+    ADD CONSTRAINT fk_departement_parent FOREIGN KEY (parent_id) REFERENCES departement(id),
+    ADD CONSTRAINT fk_departement_head FOREIGN KEY (head_employee_id) REFERENCES employee(id);
@@ -0,0 +1,5 @@
+DROP TABLE employee_event;
+
+ALTER TABLE employee
+    DROP COLUMN status,
+    DROP COLUMN position;
@@ -0,0 +1,25 @@
+ALTER TABLE employee
+    ADD COLUMN position VARCHAR(255) NULL AFTER address,
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT 'active, resigned, terminated' AFTER position;
+
+CREATE TABLE employee_event (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL,
+    event_type VARCHAR(20) NOT NULL COMMENT 'hire, transfer, promotion, resignation, termination',
+    effective_date DATE NOT NULL,
+    from_departement_id VARCHAR(50) NULL,
+    to_departement_id VARCHAR(50) NULL,
+    from_position VARCHAR(255) NULL,
+    to_position VARCHAR(255) NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50),
+    FOREIGN KEY (employee_id) REFERENCES employee(id),
+    FOREIGN KEY (from_departement_id) REFERENCES departement(id),
+    FOREIGN KEY (to_departement_id) REFERENCES departement(id),
+    INDEX idx_employee_event_effective (employee_id, effective_date)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE employee
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'employee' COMMENT 'employee, admin' AFTER status;
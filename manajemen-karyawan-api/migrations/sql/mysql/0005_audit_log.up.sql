@@ -0,0 +1,14 @@
+CREATE TABLE audit_log (
+    id VARCHAR(50) PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(50) NOT NULL,
+    action VARCHAR(20) NOT NULL COMMENT 'create, update, delete, restore',
+    actor VARCHAR(50) NOT NULL,
+    changes JSON NOT NULL COMMENT '{"field": {"old": ..., "new": ...}}',
+    request_id VARCHAR(64) NULL,
+    client_ip VARCHAR(45) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_entity (entity, entity_id),
+    INDEX idx_audit_actor (actor),
+    INDEX idx_audit_created_at (created_at)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE departement
+    ADD COLUMN timezone VARCHAR(64) NULL COMMENT 'IANA timezone, NULL = inherit from parent or company' AFTER max_clock_out_time;
@@ -0,0 +1,4 @@
+ALTER TABLE employee
+    ADD COLUMN version INT NOT NULL DEFAULT 1 COMMENT 'bumped on every write, served as ETag' AFTER role;
+ALTER TABLE departement
+    ADD COLUMN version INT NOT NULL DEFAULT 1 COMMENT 'bumped on every write, served as ETag' AFTER timezone;
@@ -0,0 +1,4 @@
+DROP TABLE attendance_history;
+DROP TABLE attendance;
+DROP TABLE employee;
+DROP TABLE departement;
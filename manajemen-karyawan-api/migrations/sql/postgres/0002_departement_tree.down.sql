@@ -0,0 +1,7 @@
+ALTER TABLE departement
+    DROP CONSTRAINT fk_departement_head,
+    DROP CONSTRAINT fk_departement_parent,
+    DROP COLUMN head_employee_id,
+    DROP COLUMN parent_id,
+    ALTER COLUMN max_clock_in_time SET NOT NULL,
+    ALTER COLUMN max_clock_out_time SET NOT NULL;
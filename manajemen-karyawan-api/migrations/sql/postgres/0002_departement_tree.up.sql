@@ -0,0 +1,8 @@
+-- NULL clock limits are inherited from the parent departement
+ALTER TABLE departement
+    ADD COLUMN parent_id VARCHAR(50) NULL,
+    ADD COLUMN head_employee_id VARCHAR(50) NULL,
+    ALTER COLUMN max_clock_in_time DROP NOT NULL,
+    ALTER COLUMN max_clock_out_time DROP NOT NULL,
+    ADD CONSTRAINT fk_departement_parent FOREIGN KEY (parent_id) REFERENCES departement(id),
+    ADD CONSTRAINT fk_departement_head FOREIGN KEY (head_employee_id) REFERENCES employee(id);
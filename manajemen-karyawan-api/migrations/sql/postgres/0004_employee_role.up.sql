@@ -0,0 +1,3 @@
+-- role: employee, admin
+ALTER TABLE employee
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'employee';
@@ -0,0 +1,17 @@
+-- action: create, update, delete, restore
+-- changes: {"field": {"old": ..., "new": ...}}
+CREATE TABLE audit_log (
+    id VARCHAR(50) PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(50) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor VARCHAR(50) NOT NULL,
+    changes JSONB NOT NULL,
+    request_id VARCHAR(64) NULL,
+    client_ip VARCHAR(45) NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_entity ON audit_log (entity, entity_id);
+CREATE INDEX idx_audit_actor ON audit_log (actor);
+CREATE INDEX idx_audit_created_at ON audit_log (created_at);
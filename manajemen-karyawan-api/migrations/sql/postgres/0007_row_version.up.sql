@@ -0,0 +1,5 @@
+-- version: bumped on every write, served as ETag
+ALTER TABLE employee
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE departement
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
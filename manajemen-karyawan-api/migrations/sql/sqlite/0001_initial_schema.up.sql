@@ -0,0 +1,56 @@
+CREATE TABLE departement (
+    id VARCHAR(50) PRIMARY KEY,
+    departement_name VARCHAR(255) NOT NULL,
+    max_clock_in_time TIME NOT NULL,
+    max_clock_out_time TIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+CREATE TABLE employee (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL UNIQUE,
+    departement_id VARCHAR(50) NOT NULL REFERENCES departement(id),
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    password VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+CREATE TABLE attendance (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id),
+    clock_in TIMESTAMP NULL DEFAULT NULL,
+    clock_out TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+-- attendance_type: 1 = IN, 2 = OUT
+CREATE TABLE attendance_history (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(employee_id),
+    attendance_id VARCHAR(50) NOT NULL REFERENCES attendance(id),
+    date_attendance TIMESTAMP NOT NULL,
+    attendance_type SMALLINT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE departement_old (
+    id VARCHAR(50) PRIMARY KEY,
+    departement_name VARCHAR(255) NOT NULL,
+    max_clock_in_time TIME NOT NULL,
+    max_clock_out_time TIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+INSERT INTO departement_old (id, departement_name, max_clock_in_time, max_clock_out_time,
+    created_at, created_by, updated_at, updated_by, deleted_at, deleted_by)
+SELECT id, departement_name, max_clock_in_time, max_clock_out_time,
+    created_at, created_by, updated_at, updated_by, deleted_at, deleted_by
+FROM departement;
+
+DROP TABLE departement;
+ALTER TABLE departement_old RENAME TO departement;
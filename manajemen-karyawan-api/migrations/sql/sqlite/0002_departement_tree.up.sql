@@ -0,0 +1,27 @@
+-- SQLite cannot relax NOT NULL in place, so departement is rebuilt. The
+-- migrator turns foreign keys off while it runs.
+
+-- NULL clock limits are inherited from the parent departement
+CREATE TABLE departement_new (
+    id VARCHAR(50) PRIMARY KEY,
+    parent_id VARCHAR(50) NULL REFERENCES departement(id),
+    head_employee_id VARCHAR(50) NULL REFERENCES employee(id),
+    departement_name VARCHAR(255) NOT NULL,
+    max_clock_in_time TIME NULL,
+    max_clock_out_time TIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+INSERT INTO departement_new (id, departement_name, max_clock_in_time, max_clock_out_time,
+    created_at, created_by, updated_at, updated_by, deleted_at, deleted_by)
+SELECT id, departement_name, max_clock_in_time, max_clock_out_time,
+    created_at, created_by, updated_at, updated_by, deleted_at, deleted_by
+FROM departement;
+
+DROP TABLE departement;
+ALTER TABLE departement_new RENAME TO departement;
@@ -0,0 +1,4 @@
+DROP TABLE employee_event;
+
+ALTER TABLE employee DROP COLUMN status;
+ALTER TABLE employee DROP COLUMN position;
@@ -0,0 +1,24 @@
+-- status: active, resigned, terminated
+ALTER TABLE employee ADD COLUMN position VARCHAR(255) NULL;
+ALTER TABLE employee ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+-- event_type: hire, transfer, promotion, resignation, termination
+CREATE TABLE employee_event (
+    id VARCHAR(50) PRIMARY KEY,
+    employee_id VARCHAR(50) NOT NULL REFERENCES employee(id),
+    event_type VARCHAR(20) NOT NULL,
+    effective_date DATE NOT NULL,
+    from_departement_id VARCHAR(50) NULL REFERENCES departement(id),
+    to_departement_id VARCHAR(50) NULL REFERENCES departement(id),
+    from_position VARCHAR(255) NULL,
+    to_position VARCHAR(255) NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(50),
+    updated_at TIMESTAMP NULL DEFAULT NULL,
+    updated_by VARCHAR(50),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by VARCHAR(50)
+);
+
+CREATE INDEX idx_employee_event_effective ON employee_event (employee_id, effective_date);
@@ -0,0 +1,2 @@
+ALTER TABLE departement
+    DROP COLUMN timezone;
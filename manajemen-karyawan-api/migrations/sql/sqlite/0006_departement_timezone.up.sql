@@ -0,0 +1,3 @@
+-- timezone: IANA timezone, NULL = inherit from parent or company
+ALTER TABLE departement
+    ADD COLUMN timezone VARCHAR(64);
@@ -0,0 +1,4 @@
+ALTER TABLE departement
+    DROP COLUMN version;
+ALTER TABLE employee
+    DROP COLUMN version;
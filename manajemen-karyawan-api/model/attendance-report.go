@@ -0,0 +1,13 @@
+package model
+
+// EmployeeMonthlyReport aggregates the attendance of one employee in a month.
+type EmployeeMonthlyReport struct {
+	EmployeeID      string  `json:"employeeID"`
+	EmployeeName    string  `json:"employeeName"`
+	DepartementName string  `json:"departementName"`
+	DaysPresent     int     `json:"daysPresent"`
+	Late            int     `json:"late"`
+	ClockOut        int     `json:"clockOut"`
+	EarlyLeave      int     `json:"earlyLeave"`
+	HoursWorked     float64 `json:"hoursWorked"`
+}
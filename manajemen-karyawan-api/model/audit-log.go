@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+)
+
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type AuditLog struct {
+	ID        string          `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entityID"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Changes   json.RawMessage `json:"changes"`
+	RequestID *string         `json:"requestID,omitempty"`
+	ClientIP  *string         `json:"clientIP,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
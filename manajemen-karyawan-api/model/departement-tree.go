@@ -0,0 +1,109 @@
+package model
+
+import "errors"
+
+var ErrDepartementCycle = errors.New("departement cannot be its own ancestor")
+
+// DepartementNode is a single departement row with its own (possibly empty)
+// clock rules and timezone, before inheritance from the parent is applied.
+type DepartementNode struct {
+	ID              string
+	ParentID        *string
+	HeadEmployeeID  *string
+	DepartementName string
+	MaxClockInTime  *string
+	MaxClockOutTime *string
+	Timezone        *string
+}
+
+// DepartementTree indexes the active departements by ID.
+type DepartementTree map[string]*DepartementNode
+
+// ancestors returns the chain from id up to the root, starting with id itself.
+func (t DepartementTree) ancestors(id string) []*DepartementNode {
+	var chain []*DepartementNode
+	seen := map[string]bool{}
+	for node, ok := t[id]; ok && !seen[node.ID]; node, ok = t[derefString(node.ParentID)] {
+		seen[node.ID] = true
+		chain = append(chain, node)
+	}
+	return chain
+}
+
+// ClockRules resolves the effective max clock-in/out times of a departement,
+// walking up to the nearest ancestor that defines each value.
+func (t DepartementTree) ClockRules(id string) (maxIn string, maxOut string) {
+	for _, node := range t.ancestors(id) {
+		if maxIn == "" && node.MaxClockInTime != nil {
+			maxIn = *node.MaxClockInTime
+		}
+		if maxOut == "" && node.MaxClockOutTime != nil {
+			maxOut = *node.MaxClockOutTime
+		}
+		if maxIn != "" && maxOut != "" {
+			break
+		}
+	}
+	return maxIn, maxOut
+}
+
+// Timezone resolves the IANA timezone of a departement from the nearest
+// ancestor that sets one. It is empty when the company timezone applies.
+func (t DepartementTree) Timezone(id string) string {
+	for _, node := range t.ancestors(id) {
+		if node.Timezone != nil && *node.Timezone != "" {
+			return *node.Timezone
+		}
+	}
+	return ""
+}
+
+// Children groups departement IDs by their parent ID. Roots are keyed by "".
+func (t DepartementTree) Children() map[string][]string {
+	children := map[string][]string{}
+	for id, node := range t {
+		parent := derefString(node.ParentID)
+		if _, ok := t[parent]; !ok {
+			parent = ""
+		}
+		children[parent] = append(children[parent], id)
+	}
+	return children
+}
+
+// Descendants returns id and every departement below it.
+func (t DepartementTree) Descendants(id string) []string {
+	children := t.Children()
+	result := []string{}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if _, ok := t[current]; !ok {
+			continue
+		}
+		result = append(result, current)
+		queue = append(queue, children[current]...)
+	}
+	return result
+}
+
+// ValidateParent checks that moving id under parentID keeps the tree acyclic.
+func (t DepartementTree) ValidateParent(id string, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	for _, node := range t.ancestors(parentID) {
+		if node.ID == id {
+			return ErrDepartementCycle
+		}
+	}
+	return nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
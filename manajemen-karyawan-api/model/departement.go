@@ -3,9 +3,33 @@ package model
 import "time"
 
 type Departement struct {
-	ID              string    `json:"id"`
-	DepartementName string    `json:"departementName"`
-	MaxClockInTime  time.Time `json:"maxClockInTime"`
-	MaxClockOutTime time.Time `json:"maxClockOutTime"`
+	ID                string    `json:"id"`
+	ParentID          *string   `json:"parentID,omitempty"`
+	HeadEmployeeID    *string   `json:"headEmployeeID,omitempty"`
+	HeadEmployeeName  *string   `json:"headEmployeeName,omitempty"`
+	DepartementName   string    `json:"departementName"`
+	MaxClockInTime    time.Time `json:"maxClockInTime"`
+	MaxClockOutTime   time.Time `json:"maxClockOutTime"`
+	ClockInInherited  bool      `json:"clockInInherited"`
+	ClockOutInherited bool      `json:"clockOutInherited"`
+	// Empty when the company timezone applies
+	Timezone          string `json:"timezone"`
+	TimezoneInherited bool   `json:"timezoneInherited"`
+	Version           int    `json:"version"`
 	Audit
 }
+
+type DepartementTreeNode struct {
+	Departement
+	Children []DepartementTreeNode `json:"children"`
+}
+
+type DepartementAttendanceSummary struct {
+	DepartementID   string                         `json:"departementID"`
+	DepartementName string                         `json:"departementName"`
+	ClockIn         int                            `json:"clockIn"`
+	Late            int                            `json:"late"`
+	ClockOut        int                            `json:"clockOut"`
+	EarlyLeave      int                            `json:"earlyLeave"`
+	Children        []DepartementAttendanceSummary `json:"children"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	EmployeeEventHire        = "hire"
+	EmployeeEventTransfer    = "transfer"
+	EmployeeEventPromotion   = "promotion"
+	EmployeeEventResignation = "resignation"
+	EmployeeEventTermination = "termination"
+)
+
+const (
+	EmployeeStatusActive     = "active"
+	EmployeeStatusResigned   = "resigned"
+	EmployeeStatusTerminated = "terminated"
+)
+
+type EmployeeEvent struct {
+	ID                string    `json:"id"`
+	EmployeeID        string    `json:"employeeID"`
+	EventType         string    `json:"eventType"`
+	EffectiveDate     time.Time `json:"effectiveDate"`
+	FromDepartementID *string   `json:"fromDepartementID,omitempty"`
+	ToDepartementID   *string   `json:"toDepartementID,omitempty"`
+	FromPosition      *string   `json:"fromPosition,omitempty"`
+	ToPosition        *string   `json:"toPosition,omitempty"`
+	Reason            *string   `json:"reason,omitempty"`
+	Audit
+}
@@ -1,12 +1,22 @@
 package model
 
+const (
+	EmployeeRoleEmployee = "employee"
+	EmployeeRoleAdmin    = "admin"
+)
+
 type Employee struct {
-	ID              string `json:"id"`
-	EmployeeID      string `json:"employeeID"`
-	DepartementID   string `json:"departementID"`
-	DepartementName string `json:"departementName"`
-	Name            string `json:"name"`
-	Password        string `json:"-"`
-	Address         string `json:"address"`
+	ID              string  `json:"id"`
+	EmployeeID      string  `json:"employeeID"`
+	DepartementID   string  `json:"departementID"`
+	DepartementName string  `json:"departementName"`
+	Name            string  `json:"name"`
+	Password        string  `json:"-"`
+	Address         string  `json:"address"`
+	Position        *string `json:"position,omitempty"`
+	Status          string  `json:"status"`
+	Role            string  `json:"role"`
+	// Version grows with every write; clients send it back in If-Match
+	Version int `json:"version"`
 	Audit
 }
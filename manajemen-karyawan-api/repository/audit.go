@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"manajemen-karyawan-api/model"
+)
+
+// auditIgnoredFields are never written to the audit trail.
+var auditIgnoredFields = map[string]bool{
+	"password": true,
+}
+
+// NormalizeAuditValue turns driver values into JSON-friendly ones.
+func NormalizeAuditValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case *string:
+		if val == nil {
+			return nil
+		}
+		return *val
+	case time.Time:
+		return val.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return nil
+		}
+		return val.UTC().Format(time.RFC3339)
+	default:
+		return val
+	}
+}
+
+// DiffValues returns the fields whose value differs between old and new.
+// A nil old map means the row was created.
+func DiffValues(old map[string]interface{}, new map[string]interface{}) map[string]model.AuditChange {
+	changes := map[string]model.AuditChange{}
+	for key, rawNew := range new {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		newVal := NormalizeAuditValue(rawNew)
+		oldVal := old[key]
+		if old != nil && fmt.Sprint(oldVal) == fmt.Sprint(newVal) && (oldVal == nil) == (newVal == nil) {
+			continue
+		}
+		changes[key] = model.AuditChange{Old: oldVal, New: newVal}
+	}
+	return changes
+}
@@ -0,0 +1,205 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+type attendanceRepository struct {
+	st *state
+}
+
+// departementOn resolves the departement employee e belonged to on the
+// business day of t, like the SQL store: the latest transfer effective by
+// then, else the origin of the first transfer, else the current departement.
+func (d data) departementOn(e model.Employee, t time.Time, loc *time.Location) string {
+	day := dateOf(t.In(loc))
+
+	var transfers []model.EmployeeEvent
+	for _, ev := range d.events {
+		if ev.EmployeeID == e.ID && ev.ToDepartementID != nil && ev.DeletedAt == nil {
+			transfers = append(transfers, ev)
+		}
+	}
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if !transfers[i].EffectiveDate.Equal(transfers[j].EffectiveDate) {
+			return transfers[i].EffectiveDate.Before(transfers[j].EffectiveDate)
+		}
+		return transfers[i].CreatedAt.Before(transfers[j].CreatedAt)
+	})
+
+	for i := len(transfers) - 1; i >= 0; i-- {
+		if !transfers[i].EffectiveDate.After(day) {
+			return *transfers[i].ToDepartementID
+		}
+	}
+	if len(transfers) > 0 && transfers[0].FromDepartementID != nil {
+		return *transfers[0].FromDepartementID
+	}
+	return e.DepartementID
+}
+
+// logRows joins the history entries accepted by keep with their attendance,
+// employee and departement on the day, newest first.
+func (d data) logRows(loc *time.Location, keep func(h model.AttendanceHistory, a model.Attendance) bool) []repository.AttendanceLogRow {
+	employees := map[string]model.Employee{}
+	for _, e := range d.employees {
+		employees[e.EmployeeID] = e
+	}
+
+	var rows []repository.AttendanceLogRow
+	for _, h := range d.history {
+		a, ok := d.attendances[h.AttendanceID]
+		if !ok || a.ClockIn == nil || !keep(h, a) {
+			continue
+		}
+		e, ok := employees[a.EmployeeID]
+		if !ok {
+			continue
+		}
+		departementID := d.departementOn(e, h.DateAttendance, loc)
+		dep, ok := d.departements[departementID]
+		if !ok {
+			continue
+		}
+		rows = append(rows, repository.AttendanceLogRow{
+			ID:              a.ID,
+			EmployeeID:      a.EmployeeID,
+			EmployeeName:    e.Name,
+			DepartementID:   departementID,
+			DepartementName: dep.node.DepartementName,
+			ClockIn:         *a.ClockIn,
+			ClockOut:        a.ClockOut,
+			DateAttendance:  h.DateAttendance,
+			AttendanceType:  h.AttendanceType,
+			Description:     h.Description,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].DateAttendance.After(rows[j].DateAttendance) })
+	return rows
+}
+
+// LockEmployee has nothing to do: transactions already run one at a time.
+func (r attendanceRepository) LockEmployee(ctx context.Context, employeeID string) error {
+	return nil
+}
+
+func (r attendanceRepository) FindByDay(ctx context.Context, employeeID string, from time.Time, to time.Time) (model.Attendance, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	for _, a := range r.st.data.attendances {
+		if a.EmployeeID != employeeID || a.DeletedAt != nil || a.ClockIn == nil {
+			continue
+		}
+		if !a.ClockIn.Before(from) && a.ClockIn.Before(to) {
+			return a, nil
+		}
+	}
+	return model.Attendance{}, repository.ErrNotFound
+}
+
+func (r attendanceRepository) Create(ctx context.Context, a model.Attendance) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.data.attendances[a.ID]; ok {
+		return errDuplicate
+	}
+	a.ClockOut = nil
+	r.st.data.attendances[a.ID] = a
+	return nil
+}
+
+func (r attendanceRepository) SetClockOut(ctx context.Context, id string, clockOut time.Time, actor string) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	a, ok := r.st.data.attendances[id]
+	if !ok {
+		return nil
+	}
+	a.ClockOut = &clockOut
+	a.UpdatedAt, a.UpdatedBy = &clockOut, &actor
+	r.st.data.attendances[id] = a
+	return nil
+}
+
+func (r attendanceRepository) AddHistory(ctx context.Context, h model.AttendanceHistory) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if h.ID == "" {
+		h.ID = utils.GenerateID()
+	}
+	r.st.data.history = append(r.st.data.history, h)
+	return nil
+}
+
+func (r attendanceRepository) ListLogs(ctx context.Context, params utils.QueryParams, employeeID string) ([]repository.AttendanceLogRow, utils.PageInfo, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	rows := r.st.data.logRows(r.st.loc, func(h model.AttendanceHistory, a model.Attendance) bool {
+		return a.DeletedAt == nil && (employeeID == "" || a.EmployeeID == employeeID)
+	})
+	from, to, info, err := page(params, len(rows))
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	return rows[from:to], info, nil
+}
+
+func (r attendanceRepository) LogsBetween(ctx context.Context, from time.Time, to time.Time) ([]repository.AttendanceLogRow, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	return r.st.data.logRows(r.st.loc, func(h model.AttendanceHistory, a model.Attendance) bool {
+		return a.DeletedAt == nil && h.DeletedAt == nil &&
+			!h.DateAttendance.Before(from) && h.DateAttendance.Before(to)
+	}), nil
+}
+
+func (r attendanceRepository) OpenBefore(ctx context.Context, before time.Time) ([]repository.AttendanceLogRow, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	rows := r.st.data.logRows(r.st.loc, func(h model.AttendanceHistory, a model.Attendance) bool {
+		return a.DeletedAt == nil && h.DeletedAt == nil &&
+			a.ClockOut == nil && a.ClockIn.Before(before) && h.AttendanceType == 1
+	})
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].ClockIn.Before(rows[j].ClockIn) })
+	return rows, nil
+}
+
+func (r attendanceRepository) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	d := &r.st.data
+	purged := map[string]int64{"attendanceHistory": 0}
+	referenced := map[string]bool{}
+	history := d.history[:0]
+	for _, h := range d.history {
+		if h.DeletedAt != nil && h.DeletedAt.Before(cutoff) {
+			purged["attendanceHistory"]++
+			continue
+		}
+		referenced[h.AttendanceID] = true
+		history = append(history, h)
+	}
+	d.history = history
+
+	for id, a := range d.attendances {
+		if a.DeletedAt != nil && a.DeletedAt.Before(cutoff) && !referenced[id] {
+			delete(d.attendances, id)
+			purged["attendance"]++
+		}
+	}
+	return purged, nil
+}
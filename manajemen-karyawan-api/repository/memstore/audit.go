@@ -0,0 +1,47 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+type auditRepository struct {
+	st *state
+}
+
+func (r auditRepository) Record(ctx context.Context, entry model.AuditLog) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = utils.GenerateID()
+	}
+	r.st.data.audit = append(r.st.data.audit, entry)
+	return nil
+}
+
+func (r auditRepository) List(ctx context.Context, filter repository.AuditFilter, pagination utils.Pagination) ([]model.AuditLog, int, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	var matched []model.AuditLog
+	for _, a := range r.st.data.audit {
+		if filter.Entity != "" && a.Entity != filter.Entity ||
+			filter.EntityID != "" && a.EntityID != filter.EntityID ||
+			filter.Actor != "" && a.Actor != filter.Actor ||
+			filter.From != nil && a.CreatedAt.Before(*filter.From) ||
+			filter.To != nil && !a.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+	// Newest first
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	from, to := bounds(pagination, len(matched))
+	return append([]model.AuditLog{}, matched[from:to]...), len(matched), nil
+}
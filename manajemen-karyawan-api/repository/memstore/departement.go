@@ -0,0 +1,356 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+var departementUpdateFields = []string{"departement_name", "parent_id", "head_employee_id", "max_clock_in_time", "max_clock_out_time", "timezone"}
+
+type departementRepository struct {
+	st *state
+}
+
+// tree indexes copies of the active departements.
+func (d data) tree() model.DepartementTree {
+	tree := model.DepartementTree{}
+	for id, dep := range d.departements {
+		if dep.audit.DeletedAt == nil {
+			node := dep.node
+			tree[id] = &node
+		}
+	}
+	return tree
+}
+
+// departement builds the model of a departement row with the clock rules
+// and timezone it inherits from tree.
+func (d data) departement(id string, tree model.DepartementTree) model.Departement {
+	dep := d.departements[id]
+	result := model.Departement{
+		ID:              id,
+		ParentID:        dep.node.ParentID,
+		HeadEmployeeID:  dep.node.HeadEmployeeID,
+		DepartementName: dep.node.DepartementName,
+		Version:         dep.version,
+		Audit:           dep.audit,
+	}
+	if dep.node.HeadEmployeeID != nil {
+		if head, ok := d.employees[*dep.node.HeadEmployeeID]; ok {
+			result.HeadEmployeeName = &head.Name
+		}
+	}
+
+	maxIn, maxOut := tree.ClockRules(id)
+	result.MaxClockInTime, _ = time.Parse("15:04:05", maxIn)
+	result.MaxClockOutTime, _ = time.Parse("15:04:05", maxOut)
+	result.Timezone = tree.Timezone(id)
+	if _, ok := tree[id]; ok {
+		result.ClockInInherited = dep.node.MaxClockInTime == nil
+		result.ClockOutInherited = dep.node.MaxClockOutTime == nil
+		result.TimezoneInherited = dep.node.Timezone == nil
+	}
+	return result
+}
+
+// activeDepartements returns the IDs of the active departements in creation
+// order.
+func (d data) activeDepartements() []string {
+	var ids []string
+	for id, dep := range d.departements {
+		if dep.audit.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	byCreation(ids, func(id string) time.Time { return d.departements[id].audit.CreatedAt })
+	return ids
+}
+
+func (r departementRepository) Tree(ctx context.Context) (model.DepartementTree, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+	return r.st.data.tree(), nil
+}
+
+func (r departementRepository) List(ctx context.Context, params utils.QueryParams) ([]model.Departement, utils.PageInfo, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	ids := r.st.data.activeDepartements()
+	from, to, info, err := page(params, len(ids))
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	tree := r.st.data.tree()
+	result := []model.Departement{}
+	for _, id := range ids[from:to] {
+		result = append(result, r.st.data.departement(id, tree))
+	}
+	return result, info, nil
+}
+
+func (r departementRepository) All(ctx context.Context) ([]model.Departement, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	tree := r.st.data.tree()
+	var result []model.Departement
+	for id := range tree {
+		result = append(result, r.st.data.departement(id, tree))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DepartementName < result[j].DepartementName })
+	return result, nil
+}
+
+func (r departementRepository) GetByID(ctx context.Context, id string) (model.Departement, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	tree := r.st.data.tree()
+	if _, ok := tree[id]; !ok {
+		return model.Departement{}, repository.ErrNotFound
+	}
+	return r.st.data.departement(id, tree), nil
+}
+
+func (r departementRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	dep, ok := r.st.data.departements[id]
+	return ok && dep.audit.DeletedAt == nil, nil
+}
+
+func (r departementRepository) IDsByName(ctx context.Context) (map[string]string, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	result := map[string]string{}
+	for id, node := range r.st.data.tree() {
+		result[strings.ToLower(strings.TrimSpace(node.DepartementName))] = id
+	}
+	return result, nil
+}
+
+func (r departementRepository) NameTaken(ctx context.Context, name string, excludeID string) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	for id, node := range r.st.data.tree() {
+		if id != excludeID && strings.EqualFold(node.DepartementName, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r departementRepository) HasChildren(ctx context.Context, id string) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	for _, node := range r.st.data.tree() {
+		if node.ParentID != nil && *node.ParentID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r departementRepository) Create(ctx context.Context, d model.DepartementNode, actor string, now time.Time) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.data.departements[d.ID]; ok {
+		return errDuplicate
+	}
+	dep := departement{node: d, version: 1}
+	dep.audit.CreatedAt = now
+	dep.audit.CreatedBy = actor
+	r.st.data.departements[d.ID] = dep
+	return nil
+}
+
+func (r departementRepository) Update(ctx context.Context, id string, fields map[string]interface{}, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	dep, ok := r.st.data.departements[id]
+	if !ok || dep.audit.DeletedAt != nil {
+		return nil, 0, repository.ErrNotFound
+	}
+	old := map[string]interface{}{
+		"departement_name":   dep.node.DepartementName,
+		"parent_id":          dep.node.ParentID,
+		"head_employee_id":   dep.node.HeadEmployeeID,
+		"max_clock_in_time":  dep.node.MaxClockInTime,
+		"max_clock_out_time": dep.node.MaxClockOutTime,
+		"timezone":           dep.node.Timezone,
+	}
+	changes, err := update(old, fields, departementUpdateFields, dep.version, ifVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for key, v := range fields {
+		switch key {
+		case "departement_name":
+			dep.node.DepartementName = stringOf(v)
+		case "parent_id":
+			dep.node.ParentID = stringPtr(v)
+		case "head_employee_id":
+			dep.node.HeadEmployeeID = stringPtr(v)
+		case "max_clock_in_time":
+			dep.node.MaxClockInTime = stringPtr(v)
+		case "max_clock_out_time":
+			dep.node.MaxClockOutTime = stringPtr(v)
+		case "timezone":
+			dep.node.Timezone = stringPtr(v)
+		}
+	}
+	dep.audit.UpdatedAt, dep.audit.UpdatedBy = &now, &actor
+	dep.version++
+	r.st.data.departements[id] = dep
+	return changes, dep.version, nil
+}
+
+func (r departementRepository) SoftDelete(ctx context.Context, id string, ifVersion int, actor string, now time.Time) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	dep, ok := r.st.data.departements[id]
+	if !ok || dep.audit.DeletedAt != nil {
+		return false, nil
+	}
+	if ifVersion != 0 && ifVersion != dep.version {
+		return false, repository.ErrVersionConflict
+	}
+	dep.audit.DeletedAt, dep.audit.DeletedBy = &now, &actor
+	dep.version++
+	r.st.data.departements[id] = dep
+	return true, nil
+}
+
+func (r departementRepository) ListDeleted(ctx context.Context, pagination utils.Pagination) ([]model.Departement, int, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	var ids []string
+	for id, dep := range r.st.data.departements {
+		if dep.audit.DeletedAt != nil {
+			ids = append(ids, id)
+		}
+	}
+	// Last deleted first
+	byCreation(ids, func(id string) time.Time { return *r.st.data.departements[id].audit.DeletedAt })
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+
+	from, to := bounds(pagination, len(ids))
+	result := []model.Departement{}
+	for _, id := range ids[from:to] {
+		// Deleted departements only show their own clock rules
+		dep := r.st.data.departements[id]
+		d := r.st.data.departement(id, model.DepartementTree{id: &dep.node})
+		d.HeadEmployeeID, d.HeadEmployeeName, d.Timezone = nil, nil, ""
+		result = append(result, d)
+	}
+	return result, len(ids), nil
+}
+
+func (r departementRepository) GetDeleted(ctx context.Context, id string) (model.Departement, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	dep, ok := r.st.data.departements[id]
+	if !ok || dep.audit.DeletedAt == nil {
+		return model.Departement{}, repository.ErrNotFound
+	}
+	return model.Departement{
+		ID:              id,
+		ParentID:        dep.node.ParentID,
+		DepartementName: dep.node.DepartementName,
+		Audit:           model.Audit{DeletedAt: dep.audit.DeletedAt, DeletedBy: dep.audit.DeletedBy},
+	}, nil
+}
+
+func (r departementRepository) Restore(ctx context.Context, id string, actor string, now time.Time) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	dep, ok := r.st.data.departements[id]
+	if !ok || dep.audit.DeletedAt == nil {
+		return repository.ErrNotFound
+	}
+	dep.audit.DeletedAt, dep.audit.DeletedBy = nil, nil
+	dep.audit.UpdatedAt, dep.audit.UpdatedBy = &now, &actor
+	dep.version++
+	r.st.data.departements[id] = dep
+	return nil
+}
+
+func (r departementRepository) ClearDeletedHeads(ctx context.Context, cutoff time.Time, actor string, now time.Time) (map[string]string, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	heads := map[string]string{}
+	for id, dep := range r.st.data.departements {
+		if dep.node.HeadEmployeeID == nil {
+			continue
+		}
+		head := *dep.node.HeadEmployeeID
+		e, ok := r.st.data.employees[head]
+		if !ok || e.DeletedAt == nil || !e.DeletedAt.Before(cutoff) {
+			continue
+		}
+		heads[id] = head
+		dep.node.HeadEmployeeID = nil
+		dep.audit.UpdatedAt, dep.audit.UpdatedBy = &now, &actor
+		dep.version++
+		r.st.data.departements[id] = dep
+	}
+	return heads, nil
+}
+
+func (r departementRepository) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	d := &r.st.data
+	referenced := map[string]bool{}
+	for _, e := range d.employees {
+		referenced[e.DepartementID] = true
+	}
+	for _, dep := range d.departements {
+		if dep.node.ParentID != nil {
+			referenced[*dep.node.ParentID] = true
+		}
+	}
+	for _, ev := range d.events {
+		for _, id := range []*string{ev.FromDepartementID, ev.ToDepartementID} {
+			if id != nil {
+				referenced[*id] = true
+			}
+		}
+	}
+
+	purged := map[string]int64{"departementSkipped": 0}
+	for id, dep := range d.departements {
+		if dep.audit.DeletedAt == nil || !dep.audit.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if referenced[id] {
+			purged["departementSkipped"]++
+			continue
+		}
+		delete(d.departements, id)
+		purged["departement"]++
+	}
+	return purged, nil
+}
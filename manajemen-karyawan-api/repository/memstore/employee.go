@@ -0,0 +1,364 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+var employeeUpdateFields = []string{"name", "departement_id", "address", "position", "status"}
+
+type employeeRepository struct {
+	st *state
+}
+
+// withDepartement fills in the departement name and leaves out the password
+// hash, like the SQL store's listings.
+func (d data) withDepartement(e model.Employee) model.Employee {
+	e.DepartementName = d.departements[e.DepartementID].node.DepartementName
+	e.Password = ""
+	return e
+}
+
+// activeEmployees returns the IDs of the active employees in creation order.
+func (d data) activeEmployees() []string {
+	var ids []string
+	for id, e := range d.employees {
+		if e.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	byCreation(ids, func(id string) time.Time { return d.employees[id].CreatedAt })
+	return ids
+}
+
+func (r employeeRepository) List(ctx context.Context, params utils.QueryParams) ([]model.Employee, utils.PageInfo, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	ids := r.st.data.activeEmployees()
+	from, to, info, err := page(params, len(ids))
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	result := []model.Employee{}
+	for _, id := range ids[from:to] {
+		result = append(result, r.st.data.withDepartement(r.st.data.employees[id]))
+	}
+	return result, info, nil
+}
+
+func (r employeeRepository) GetByID(ctx context.Context, id string) (model.Employee, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	if !ok || e.DeletedAt != nil {
+		return model.Employee{}, repository.ErrNotFound
+	}
+	return r.st.data.withDepartement(e), nil
+}
+
+func (r employeeRepository) GetByEmployeeID(ctx context.Context, employeeID string) (model.Employee, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	for _, e := range r.st.data.employees {
+		if e.EmployeeID == employeeID && e.DeletedAt == nil {
+			return e, nil
+		}
+	}
+	return model.Employee{}, repository.ErrNotFound
+}
+
+func (r employeeRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	return ok && e.DeletedAt == nil, nil
+}
+
+func (r employeeRepository) EmployeeIDTaken(ctx context.Context, employeeID string) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	for _, e := range r.st.data.employees {
+		if e.EmployeeID == employeeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r employeeRepository) EmployeeIDsInUse(ctx context.Context) (map[string]bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	inUse := map[string]bool{}
+	for _, e := range r.st.data.employees {
+		inUse[strings.ToLower(e.EmployeeID)] = e.DeletedAt != nil
+	}
+	return inUse, nil
+}
+
+func (r employeeRepository) IDsInDepartement(ctx context.Context, departementID string) ([]string, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	var ids []string
+	for _, id := range r.st.data.activeEmployees() {
+		if r.st.data.employees[id].DepartementID == departementID {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r employeeRepository) ListByIDs(ctx context.Context, ids []string) ([]model.Employee, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var result []model.Employee
+	for _, id := range r.st.data.activeEmployees() {
+		if len(ids) == 0 || wanted[id] {
+			result = append(result, r.st.data.withDepartement(r.st.data.employees[id]))
+		}
+	}
+	return result, nil
+}
+
+func (r employeeRepository) Create(ctx context.Context, e model.Employee) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.data.employees[e.ID]; ok {
+		return errDuplicate
+	}
+	for _, other := range r.st.data.employees {
+		if other.EmployeeID == e.EmployeeID {
+			return errDuplicate
+		}
+	}
+	if e.Role == "" {
+		e.Role = model.EmployeeRoleEmployee
+	}
+	e.DepartementName = ""
+	e.Version = 1
+	r.st.data.employees[e.ID] = e
+	return nil
+}
+
+func (r employeeRepository) Update(ctx context.Context, id string, fields map[string]interface{}, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	if !ok || e.DeletedAt != nil {
+		return nil, 0, repository.ErrNotFound
+	}
+	old := map[string]interface{}{
+		"name":           e.Name,
+		"departement_id": e.DepartementID,
+		"address":        e.Address,
+		"position":       e.Position,
+		"status":         e.Status,
+	}
+	changes, err := update(old, fields, employeeUpdateFields, e.Version, ifVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for key, v := range fields {
+		switch key {
+		case "name":
+			e.Name = stringOf(v)
+		case "departement_id":
+			e.DepartementID = stringOf(v)
+		case "address":
+			e.Address = stringOf(v)
+		case "position":
+			e.Position = stringPtr(v)
+		case "status":
+			e.Status = stringOf(v)
+		}
+	}
+	e.UpdatedAt, e.UpdatedBy = &now, &actor
+	e.Version++
+	r.st.data.employees[id] = e
+	return changes, e.Version, nil
+}
+
+func (r employeeRepository) SetPassword(ctx context.Context, id string, hash string, actor string, now time.Time) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	if !ok || e.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	e.Password = hash
+	e.UpdatedAt, e.UpdatedBy = &now, &actor
+	e.Version++
+	r.st.data.employees[id] = e
+	return nil
+}
+
+func (r employeeRepository) SoftDelete(ctx context.Context, id string, ifVersion int, actor string, now time.Time) (bool, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	if !ok || e.DeletedAt != nil {
+		return false, nil
+	}
+	if ifVersion != 0 && ifVersion != e.Version {
+		return false, repository.ErrVersionConflict
+	}
+	e.DeletedAt, e.DeletedBy = &now, &actor
+	e.Version++
+	r.st.data.employees[id] = e
+	return true, nil
+}
+
+// deletedEmployees returns the IDs of the deleted employees, last deleted
+// first.
+func (d data) deletedEmployees() []string {
+	var ids []string
+	for id, e := range d.employees {
+		if e.DeletedAt != nil {
+			ids = append(ids, id)
+		}
+	}
+	byCreation(ids, func(id string) time.Time { return *d.employees[id].DeletedAt })
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	return ids
+}
+
+func (r employeeRepository) ListDeleted(ctx context.Context, pagination utils.Pagination) ([]model.Employee, int, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	ids := r.st.data.deletedEmployees()
+	from, to := bounds(pagination, len(ids))
+	result := []model.Employee{}
+	for _, id := range ids[from:to] {
+		result = append(result, r.st.data.withDepartement(r.st.data.employees[id]))
+	}
+	return result, len(ids), nil
+}
+
+func (r employeeRepository) GetDeleted(ctx context.Context, id string) (model.Employee, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	if !ok || e.DeletedAt == nil {
+		return model.Employee{}, repository.ErrNotFound
+	}
+	return r.st.data.withDepartement(e), nil
+}
+
+func (r employeeRepository) Restore(ctx context.Context, id string, actor string, now time.Time) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	e, ok := r.st.data.employees[id]
+	if !ok || e.DeletedAt == nil {
+		return repository.ErrNotFound
+	}
+	e.DeletedAt, e.DeletedBy = nil, nil
+	e.UpdatedAt, e.UpdatedBy = &now, &actor
+	e.Version++
+	r.st.data.employees[id] = e
+	return nil
+}
+
+func (r employeeRepository) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	d := &r.st.data
+	purged := map[string]int64{}
+	codes := map[string]bool{}
+	for id, e := range d.employees {
+		if e.DeletedAt == nil || !e.DeletedAt.Before(cutoff) {
+			continue
+		}
+		codes[e.EmployeeID] = true
+		delete(d.employees, id)
+		purged["employee"]++
+		events := d.events[:0]
+		for _, ev := range d.events {
+			if ev.EmployeeID == id {
+				purged["employeeEvent"]++
+				continue
+			}
+			events = append(events, ev)
+		}
+		d.events = events
+	}
+	if len(codes) == 0 {
+		return purged, nil
+	}
+
+	history := d.history[:0]
+	for _, h := range d.history {
+		if codes[h.EmployeeID] {
+			purged["attendanceHistory"]++
+			continue
+		}
+		history = append(history, h)
+	}
+	d.history = history
+	for id, a := range d.attendances {
+		if codes[a.EmployeeID] {
+			delete(d.attendances, id)
+			purged["attendance"]++
+		}
+	}
+	return purged, nil
+}
+
+func (r employeeRepository) ListEvents(ctx context.Context, id string) ([]model.EmployeeEvent, error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	result := []model.EmployeeEvent{}
+	for _, ev := range r.st.data.events {
+		if ev.EmployeeID == id && ev.DeletedAt == nil {
+			result = append(result, ev)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].EffectiveDate.Equal(result[j].EffectiveDate) {
+			return result[i].EffectiveDate.Before(result[j].EffectiveDate)
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r employeeRepository) CreateEvent(ctx context.Context, ev model.EmployeeEvent) error {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if ev.ID == "" {
+		ev.ID = utils.GenerateID()
+	}
+	// effective_date is a DATE column
+	ev.EffectiveDate = dateOf(ev.EffectiveDate)
+	r.st.data.events = append(r.st.data.events, ev)
+	return nil
+}
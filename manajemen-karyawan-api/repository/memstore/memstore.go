@@ -0,0 +1,219 @@
+// Package memstore is an in-memory repository.Store for tests of the
+// handlers and services, keeping rows in maps instead of a database.
+//
+// It follows the contracts of the SQL store (versions, soft deletes,
+// ErrNotFound and ErrVersionConflict) but not its query features: list
+// endpoints page by offset in creation order and reject filters, sorts and
+// cursors. Transactions roll back by restoring a snapshot and are not
+// isolated from writes made outside of them.
+package memstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+var (
+	// ErrUnsupported is returned by List when params ask for more than paging.
+	ErrUnsupported = errors.New("memstore: filter, sort_by and cursor are not supported")
+	// errDuplicate stands in for a unique constraint violation.
+	errDuplicate = errors.New("memstore: duplicate key")
+)
+
+// departement is a departement row: its own values plus bookkeeping.
+type departement struct {
+	node    model.DepartementNode
+	version int
+	audit   model.Audit
+}
+
+type data struct {
+	employees    map[string]model.Employee
+	events       []model.EmployeeEvent
+	departements map[string]departement
+	attendances  map[string]model.Attendance
+	history      []model.AttendanceHistory
+	audit        []model.AuditLog
+}
+
+func (d data) clone() data {
+	c := data{
+		employees:    make(map[string]model.Employee, len(d.employees)),
+		events:       append([]model.EmployeeEvent(nil), d.events...),
+		departements: make(map[string]departement, len(d.departements)),
+		attendances:  make(map[string]model.Attendance, len(d.attendances)),
+		history:      append([]model.AttendanceHistory(nil), d.history...),
+		audit:        append([]model.AuditLog(nil), d.audit...),
+	}
+	for id, e := range d.employees {
+		c.employees[id] = e
+	}
+	for id, dep := range d.departements {
+		c.departements[id] = dep
+	}
+	for id, a := range d.attendances {
+		c.attendances[id] = a
+	}
+	return c
+}
+
+type state struct {
+	mu sync.Mutex
+	// Held for the whole of a transaction, so they run one at a time
+	txMu sync.Mutex
+	data data
+	loc  *time.Location
+}
+
+// Store is an in-memory repository.Store.
+type Store struct {
+	st *state
+	tx bool
+}
+
+var _ repository.Store = (*Store)(nil)
+
+// New returns an empty Store. loc is the business timezone that decides
+// which day an attendance belongs to, UTC when nil.
+func New(loc *time.Location) *Store {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Store{st: &state{
+		data: data{
+			employees:    map[string]model.Employee{},
+			departements: map[string]departement{},
+			attendances:  map[string]model.Attendance{},
+		},
+		loc: loc,
+	}}
+}
+
+func (s *Store) Employees() repository.EmployeeRepository {
+	return employeeRepository{s.st}
+}
+
+func (s *Store) Departements() repository.DepartementRepository {
+	return departementRepository{s.st}
+}
+
+func (s *Store) Attendance() repository.AttendanceRepository {
+	return attendanceRepository{s.st}
+}
+
+func (s *Store) Audit() repository.AuditRepository {
+	return auditRepository{s.st}
+}
+
+func (s *Store) WithTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	// Already inside a transaction, join it
+	if s.tx {
+		return fn(s)
+	}
+
+	s.st.txMu.Lock()
+	defer s.st.txMu.Unlock()
+
+	s.st.mu.Lock()
+	snapshot := s.st.data.clone()
+	s.st.mu.Unlock()
+
+	if err := fn(&Store{st: s.st, tx: true}); err != nil {
+		s.st.mu.Lock()
+		s.st.data = snapshot
+		s.st.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// AuditLogs returns every recorded audit entry, oldest first.
+func (s *Store) AuditLogs() []model.AuditLog {
+	s.st.mu.Lock()
+	defer s.st.mu.Unlock()
+	return append([]model.AuditLog(nil), s.st.data.audit...)
+}
+
+// page applies the offset pagination of params to n rows, returning the
+// bounds of the page and its PageInfo.
+func page(params utils.QueryParams, n int) (int, int, utils.PageInfo, error) {
+	if len(params.Filter) > 0 || len(params.SortBy) > 0 || params.Cursor != nil {
+		return 0, 0, utils.PageInfo{}, ErrUnsupported
+	}
+	info := utils.PageInfo{Total: &n}
+	pagination := utils.BuildPagination(params.Page, params.PerPage)
+	from, to := bounds(pagination, n)
+	return from, to, info, nil
+}
+
+// bounds returns the slice bounds of pagination over n rows.
+func bounds(pagination utils.Pagination, n int) (int, int) {
+	if !pagination.Use {
+		return 0, n
+	}
+	from := min(pagination.Offset, n)
+	return from, min(from+pagination.Limit, n)
+}
+
+// byCreation orders ids by the creation time given by at, then by id.
+func byCreation(ids []string, at func(id string) time.Time) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := at(ids[i]), at(ids[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return ids[i] < ids[j]
+	})
+}
+
+// update checks an update of the row with values old and version current the
+// way the SQL store's auditedUpdate does, and returns what fields change.
+func update(old map[string]interface{}, fields map[string]interface{}, whitelist []string, current int, ifVersion int) (map[string]model.AuditChange, error) {
+	if ifVersion != 0 && ifVersion != current {
+		return nil, repository.ErrVersionConflict
+	}
+	for key := range fields {
+		if !slices.Contains(whitelist, key) {
+			return nil, fmt.Errorf("field %s is not allowed to be updated", key)
+		}
+	}
+
+	normalized := map[string]interface{}{}
+	for key := range fields {
+		normalized[key] = repository.NormalizeAuditValue(old[key])
+	}
+	return repository.DiffValues(normalized, fields), nil
+}
+
+func stringPtr(v interface{}) *string {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case *string:
+		return val
+	case string:
+		return &val
+	}
+	return nil
+}
+
+func stringOf(v interface{}) string {
+	if s := stringPtr(v); s != nil {
+		return *s
+	}
+	return ""
+}
+
+// dateOf keeps the calendar date of t, as a DATE column stores it.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
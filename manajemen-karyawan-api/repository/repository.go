@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/utils"
+)
+
+// ErrNotFound is returned when a single row lookup matches nothing.
+var ErrNotFound = apperr.NotFound("record not found")
+
+// ErrVersionConflict is returned when a write expects a row version that is
+// no longer current, i.e. someone else changed the row in between.
+var ErrVersionConflict = apperr.New(apperr.CodePreconditionFailed, "record was modified since it was read")
+
+// Querier is satisfied by both *sql.DB and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Store groups the repositories of every aggregate. Repositories obtained
+// from the Store passed to WithTx's callback share its transaction.
+type Store interface {
+	Employees() EmployeeRepository
+	Departements() DepartementRepository
+	Attendance() AttendanceRepository
+	Audit() AuditRepository
+
+	// WithTx runs fn in a transaction, committing when fn returns nil and
+	// rolling back otherwise.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+type EmployeeRepository interface {
+	List(ctx context.Context, params utils.QueryParams) ([]model.Employee, utils.PageInfo, error)
+	GetByID(ctx context.Context, id string) (model.Employee, error)
+	// GetByEmployeeID looks up an active (not deleted) employee by its
+	// employee code, including the password hash.
+	GetByEmployeeID(ctx context.Context, employeeID string) (model.Employee, error)
+	Exists(ctx context.Context, id string) (bool, error)
+	// EmployeeIDTaken reports whether any employee, including soft-deleted
+	// ones, uses the employee code. Codes are never reused: attendance rows
+	// reference employee.employee_id, which is unique across deleted rows.
+	EmployeeIDTaken(ctx context.Context, employeeID string) (bool, error)
+	// EmployeeIDsInUse maps every lower-cased employee code, including
+	// soft-deleted ones, to whether its row is deleted.
+	EmployeeIDsInUse(ctx context.Context) (map[string]bool, error)
+	// IDsInDepartement locks and returns the active employees of a departement.
+	IDsInDepartement(ctx context.Context, departementID string) ([]string, error)
+	// ListByIDs returns the active employees among ids with their
+	// departement name, or every active employee when ids is empty.
+	ListByIDs(ctx context.Context, ids []string) ([]model.Employee, error)
+
+	Create(ctx context.Context, e model.Employee) error
+	// Update sets fields and returns what changed and the row's new version.
+	// A non-zero ifVersion must equal the current version, otherwise
+	// ErrVersionConflict is returned; a missing row gives ErrNotFound.
+	Update(ctx context.Context, id string, fields map[string]interface{}, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error)
+	// SetPassword replaces the password hash of an active employee.
+	SetPassword(ctx context.Context, id string, hash string, actor string, now time.Time) error
+	// SoftDelete reports false when no active row matched. A non-zero
+	// ifVersion is checked like in Update.
+	SoftDelete(ctx context.Context, id string, ifVersion int, actor string, now time.Time) (bool, error)
+
+	ListDeleted(ctx context.Context, pagination utils.Pagination) ([]model.Employee, int, error)
+	GetDeleted(ctx context.Context, id string) (model.Employee, error)
+	Restore(ctx context.Context, id string, actor string, now time.Time) error
+	// Purge permanently deletes employees soft-deleted before cutoff together
+	// with their attendance and events, returning row counts per table.
+	// Departements they head must be released first with
+	// DepartementRepository.ClearDeletedHeads.
+	Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error)
+
+	ListEvents(ctx context.Context, id string) ([]model.EmployeeEvent, error)
+	CreateEvent(ctx context.Context, ev model.EmployeeEvent) error
+}
+
+type DepartementRepository interface {
+	// Tree loads every active departement with its own clock rules.
+	Tree(ctx context.Context) (model.DepartementTree, error)
+	List(ctx context.Context, params utils.QueryParams) ([]model.Departement, utils.PageInfo, error)
+	// All returns every active departement ordered by name.
+	All(ctx context.Context) ([]model.Departement, error)
+	GetByID(ctx context.Context, id string) (model.Departement, error)
+	Exists(ctx context.Context, id string) (bool, error)
+	// IDsByName maps lower-cased departement names to their ID.
+	IDsByName(ctx context.Context) (map[string]string, error)
+	NameTaken(ctx context.Context, name string, excludeID string) (bool, error)
+	// HasChildren reports whether a departement has active children, locking
+	// them until the transaction ends.
+	HasChildren(ctx context.Context, id string) (bool, error)
+
+	Create(ctx context.Context, d model.DepartementNode, actor string, now time.Time) error
+	// Update and SoftDelete check ifVersion like their EmployeeRepository
+	// counterparts.
+	Update(ctx context.Context, id string, fields map[string]interface{}, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error)
+	SoftDelete(ctx context.Context, id string, ifVersion int, actor string, now time.Time) (bool, error)
+
+	ListDeleted(ctx context.Context, pagination utils.Pagination) ([]model.Departement, int, error)
+	GetDeleted(ctx context.Context, id string) (model.Departement, error)
+	Restore(ctx context.Context, id string, actor string, now time.Time) error
+	// ClearDeletedHeads unsets the head of every departement headed by an
+	// employee soft-deleted before cutoff, bumping its version, and returns
+	// the former head by departement ID.
+	ClearDeletedHeads(ctx context.Context, cutoff time.Time, actor string, now time.Time) (map[string]string, error)
+	// Purge permanently deletes departements soft-deleted before cutoff that
+	// are no longer referenced; the rest are counted as departementSkipped.
+	Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error)
+}
+
+// AttendanceLogRow is one attendance_history entry joined with its attendance,
+// employee and the departement the employee belonged to on that day.
+type AttendanceLogRow struct {
+	ID              string
+	EmployeeID      string
+	EmployeeName    string
+	DepartementID   string
+	DepartementName string
+	ClockIn         time.Time
+	ClockOut        *time.Time
+	DateAttendance  time.Time
+	AttendanceType  int
+	Description     string
+}
+
+type AttendanceRepository interface {
+	// LockEmployee holds the employee with code employeeID until the
+	// transaction ends, so their clock requests run one at a time.
+	LockEmployee(ctx context.Context, employeeID string) error
+	// FindByDay returns the attendance of an employee clocked in within [from, to).
+	FindByDay(ctx context.Context, employeeID string, from time.Time, to time.Time) (model.Attendance, error)
+	Create(ctx context.Context, a model.Attendance) error
+	SetClockOut(ctx context.Context, id string, clockOut time.Time, actor string) error
+	AddHistory(ctx context.Context, h model.AttendanceHistory) error
+
+	// ListLogs lists attendance history of one employee, or of everyone when
+	// employeeID is empty.
+	ListLogs(ctx context.Context, params utils.QueryParams, employeeID string) ([]AttendanceLogRow, utils.PageInfo, error)
+	// LogsBetween returns all history entries dated within [from, to).
+	LogsBetween(ctx context.Context, from time.Time, to time.Time) ([]AttendanceLogRow, error)
+	// OpenBefore returns the clock-in entries of attendances clocked in
+	// before the given time that were never clocked out.
+	OpenBefore(ctx context.Context, before time.Time) ([]AttendanceLogRow, error)
+	Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error)
+}
+
+// AuditFilter narrows an audit log listing; empty fields are ignored.
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	Actor    string
+	From     *time.Time
+	To       *time.Time
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry model.AuditLog) error
+	List(ctx context.Context, filter AuditFilter, pagination utils.Pagination) ([]model.AuditLog, int, error)
+}
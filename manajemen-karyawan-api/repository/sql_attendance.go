@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/utils"
+)
+
+var attendanceFields = utils.Fields{
+	"dateAttendance": {Column: "h.date_attendance", Type: utils.DateField},
+	// Key the Vue frontend sent before the registry existed
+	"date_attendance": {Column: "h.date_attendance", Type: utils.DateField},
+	"employeeID":      {Column: "a.employee_id"},
+	"employeeName":    {Column: "e.name"},
+	"departementID":   {Column: "d.id"},
+	"departementName": {Column: "d.departement_name"},
+	"attendanceType": {Column: "h.attendance_type", Type: utils.EnumField, Values: map[string]interface{}{
+		"in": 1, "out": 2, "1": 1, "2": 2,
+	}},
+	"description": {Column: "h.description"},
+	"clockIn":     {Column: "a.clock_in", Type: utils.DateField},
+	"clockOut":    {Column: "a.clock_out", Type: utils.DateField, Nullable: true},
+}
+
+// employeeDepartementOnDate resolves the departement an employee belonged to
+// on the business day of h.date_attendance. Days before the first transfer
+// belong to the departement it moved the employee out of; only employees
+// without any event fall back to their current departement. The day is taken
+// in the company timezone loc, not the departement's own, since the
+// departement is what is being resolved: a transfer applies from midnight
+// company time.
+func employeeDepartementOnDate(d dialect.Dialect, loc *time.Location) string {
+	return `COALESCE((
+	SELECT ev.to_departement_id FROM employee_event ev
+	WHERE ev.employee_id = e.id
+	AND ev.to_departement_id IS NOT NULL
+	AND ev.deleted_at IS NULL
+	AND ev.effective_date <= ` + d.LocalDate("h.date_attendance", loc) + `
+	ORDER BY ev.effective_date DESC, ev.created_at DESC
+	LIMIT 1
+), (
+	SELECT ev.from_departement_id FROM employee_event ev
+	WHERE ev.employee_id = e.id
+	AND ev.to_departement_id IS NOT NULL
+	AND ev.deleted_at IS NULL
+	ORDER BY ev.effective_date ASC, ev.created_at ASC
+	LIMIT 1
+), e.departement_id)`
+}
+
+func attendanceLogFrom(d dialect.Dialect, loc *time.Location) string {
+	return `
+	FROM attendance a
+	JOIN employee e ON a.employee_id = e.employee_id
+	JOIN attendance_history h ON h.attendance_id = a.id
+	JOIN departement d ON d.id = ` + employeeDepartementOnDate(d, loc)
+}
+
+const attendanceLogColumns = `
+	SELECT 
+		a.id,
+		a.employee_id,
+		e.name AS employee_name,
+		d.id,
+		d.departement_name,
+		a.clock_in,
+		a.clock_out,
+		h.date_attendance,
+		h.attendance_type,
+		h.description
+`
+
+func attendanceLogSelect(d dialect.Dialect, loc *time.Location) string {
+	return attendanceLogColumns + attendanceLogFrom(d, loc)
+}
+
+// attendanceKeyset pages history entries newest first; h.id is unique per
+// entry while a.id is shared by the IN and OUT of a day.
+var attendanceKeyset = utils.Keyset{ID: "h.id", Default: utils.SortField{Key: "dateAttendance", Order: "desc"}}
+
+type sqlAttendanceRepository struct {
+	q   Querier
+	d   dialect.Dialect
+	loc *time.Location
+}
+
+func scanAttendanceLogs(rows rowIterator) ([]AttendanceLogRow, error) {
+	var result []AttendanceLogRow
+	for rows.Next() {
+		var l AttendanceLogRow
+		err := rows.Scan(
+			&l.ID, &l.EmployeeID, &l.EmployeeName, &l.DepartementID, &l.DepartementName,
+			&l.ClockIn, &l.ClockOut, &l.DateAttendance, &l.AttendanceType, &l.Description,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, l)
+	}
+	return result, rows.Err()
+}
+
+func (r *sqlAttendanceRepository) LockEmployee(ctx context.Context, employeeID string) error {
+	_, err := selectIDs(ctx, r.q, `SELECT id FROM employee WHERE employee_id = ? `+r.d.ForUpdate(), employeeID)
+	return err
+}
+
+func (r *sqlAttendanceRepository) FindByDay(ctx context.Context, employeeID string, from time.Time, to time.Time) (model.Attendance, error) {
+	var a model.Attendance
+	err := r.q.QueryRowContext(ctx, `
+		SELECT id, employee_id, clock_in, clock_out, created_at, created_by
+		FROM attendance
+		WHERE employee_id = ? AND clock_in >= ? AND clock_in < ? AND deleted_at IS NULL
+		LIMIT 1
+	`, employeeID, from, to).Scan(&a.ID, &a.EmployeeID, &a.ClockIn, &a.ClockOut, &a.CreatedAt, &a.CreatedBy)
+	if err == sql.ErrNoRows {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+func (r *sqlAttendanceRepository) Create(ctx context.Context, a model.Attendance) error {
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO attendance (id, employee_id, clock_in, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?)
+	`, a.ID, a.EmployeeID, a.ClockIn, a.CreatedAt, a.CreatedBy)
+	return err
+}
+
+func (r *sqlAttendanceRepository) SetClockOut(ctx context.Context, id string, clockOut time.Time, actor string) error {
+	_, err := r.q.ExecContext(ctx, `
+		UPDATE attendance SET clock_out = ?, updated_at = ?, updated_by = ?
+		WHERE id = ?
+	`, clockOut, clockOut, actor, id)
+	return err
+}
+
+func (r *sqlAttendanceRepository) AddHistory(ctx context.Context, h model.AttendanceHistory) error {
+	if h.ID == "" {
+		h.ID = utils.GenerateID()
+	}
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO attendance_history (id, employee_id, attendance_id, date_attendance, attendance_type, description, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.ID, h.EmployeeID, h.AttendanceID, h.DateAttendance, h.AttendanceType, h.Description, h.CreatedAt, h.CreatedBy)
+	return err
+}
+
+func (r *sqlAttendanceRepository) ListLogs(ctx context.Context, params utils.QueryParams, employeeID string) ([]AttendanceLogRow, utils.PageInfo, error) {
+	page, err := utils.BuildPage(params, attendanceFields, attendanceKeyset)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	filterSQL, filterArgs, err := utils.BuildFilterSQL(params.Filter, attendanceFields, params.Location)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+
+	where := "WHERE a.deleted_at IS NULL"
+	var args []interface{}
+	if employeeID != "" {
+		where += " AND a.employee_id = ?"
+		args = append(args, employeeID)
+	}
+	args = append(args, filterArgs...)
+
+	query, queryArgs := page.Limit(fmt.Sprintf(`
+		%s
+		%s
+		%s
+		%s
+		%s
+		%s
+		%s
+	`, attendanceLogColumns, page.Select, attendanceLogFrom(r.d, r.loc), where, filterSQL, page.Where, page.OrderBy), append(append([]interface{}{}, args...), page.Args...))
+
+	rows, err := r.q.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	defer rows.Close()
+
+	result, err := scanAttendanceLogs(page.Rows(rows))
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	result = result[:page.Keep(len(result))]
+
+	var total int
+	if page.Counted() {
+		err = r.q.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COUNT(*)
+			%s
+			%s
+			%s
+		`, attendanceLogFrom(r.d, r.loc), where, filterSQL), args...).Scan(&total)
+	}
+	return result, page.Info(total), err
+}
+
+func (r *sqlAttendanceRepository) LogsBetween(ctx context.Context, from time.Time, to time.Time) ([]AttendanceLogRow, error) {
+	rows, err := r.q.QueryContext(ctx, attendanceLogSelect(r.d, r.loc)+`
+		WHERE a.deleted_at IS NULL AND h.deleted_at IS NULL
+		AND h.date_attendance >= ? AND h.date_attendance < ?
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttendanceLogs(rows)
+}
+
+func (r *sqlAttendanceRepository) OpenBefore(ctx context.Context, before time.Time) ([]AttendanceLogRow, error) {
+	rows, err := r.q.QueryContext(ctx, attendanceLogSelect(r.d, r.loc)+`
+		WHERE a.deleted_at IS NULL AND h.deleted_at IS NULL
+		AND a.clock_out IS NULL AND a.clock_in < ?
+		AND h.attendance_type = 1
+		ORDER BY a.clock_in
+	`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttendanceLogs(rows)
+}
+
+func (r *sqlAttendanceRepository) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	purged := map[string]int64{}
+
+	n, err := execCount(ctx, r.q, `
+		DELETE FROM attendance_history WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	purged["attendanceHistory"] = n
+
+	ids, err := selectIDs(ctx, r.q, `
+		SELECT a.id FROM attendance a
+		WHERE a.deleted_at IS NOT NULL AND a.deleted_at < ?
+		AND NOT EXISTS (SELECT 1 FROM attendance_history h WHERE h.attendance_id = a.id)
+	`, cutoff)
+	if err != nil || len(ids) == 0 {
+		return purged, err
+	}
+
+	n, err = execCount(ctx, r.q, fmt.Sprintf(`DELETE FROM attendance WHERE id IN (%s)`, utils.Placeholders(len(ids))), ids...)
+	purged["attendance"] = n
+	return purged, err
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/utils"
+)
+
+type sqlAuditRepository struct {
+	q Querier
+}
+
+func (r *sqlAuditRepository) Record(ctx context.Context, entry model.AuditLog) error {
+	if entry.ID == "" {
+		entry.ID = utils.GenerateID()
+	}
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO audit_log (id, entity, entity_id, action, actor, changes, request_id, client_ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.Entity, entry.EntityID, entry.Action, entry.Actor, string(entry.Changes),
+		entry.RequestID, entry.ClientIP, entry.CreatedAt)
+	return err
+}
+
+func (r *sqlAuditRepository) List(ctx context.Context, filter AuditFilter, pagination utils.Pagination) ([]model.AuditLog, int, error) {
+	var (
+		clauses []string
+		args    []interface{}
+	)
+	for col, v := range map[string]string{
+		"entity":    filter.Entity,
+		"entity_id": filter.EntityID,
+		"actor":     filter.Actor,
+	} {
+		if v != "" {
+			clauses = append(clauses, col+" = ?")
+			args = append(args, v)
+		}
+	}
+	if filter.From != nil {
+		clauses = append(clauses, "created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		clauses = append(clauses, "created_at < ?")
+		args = append(args, *filter.To)
+	}
+
+	where := ""
+	if len(clauses) > 0 {
+		where = "WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	query, queryArgs := limitSQL(fmt.Sprintf(`
+		SELECT id, entity, entity_id, action, actor, changes, request_id, client_ip, created_at
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC
+	`, where), append([]interface{}{}, args...), pagination)
+
+	rows, err := r.q.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	result := []model.AuditLog{}
+	for rows.Next() {
+		var (
+			a       model.AuditLog
+			changes []byte
+		)
+		err := rows.Scan(&a.ID, &a.Entity, &a.EntityID, &a.Action, &a.Actor, &changes,
+			&a.RequestID, &a.ClientIP, &a.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		a.Changes = json.RawMessage(changes)
+		if !json.Valid(a.Changes) {
+			a.Changes = json.RawMessage("null")
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.q.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM audit_log %s`, where), args...).Scan(&total)
+	return result, total, err
+}
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/utils"
+)
+
+// departementFields filter on a departement's own clock rules and timezone;
+// NULL means the value is inherited.
+var departementFields = utils.Fields{
+	"id":              {Column: "d.id"},
+	"parentID":        {Column: "d.parent_id", Nullable: true},
+	"headEmployeeID":  {Column: "d.head_employee_id", Nullable: true},
+	"departementName": {Column: "d.departement_name"},
+	"maxClockInTime":  {Column: "d.max_clock_in_time", Type: utils.ClockField, Nullable: true},
+	"maxClockOutTime": {Column: "d.max_clock_out_time", Type: utils.ClockField, Nullable: true},
+	"timezone":        {Column: "d.timezone", Nullable: true},
+	"createdAt":       {Column: "d.created_at", Type: utils.DateField},
+}
+
+var departementUpdateFields = []string{"departement_name", "parent_id", "head_employee_id", "max_clock_in_time", "max_clock_out_time", "timezone"}
+
+const (
+	departementColumns = `
+	SELECT d.id, d.parent_id, d.head_employee_id, h.name, d.departement_name, d.version,
+	       d.created_at, d.created_by, d.updated_at, d.updated_by, d.deleted_at, d.deleted_by
+`
+	departementFrom = `
+	FROM departement d
+	LEFT JOIN employee h ON h.id = d.head_employee_id AND h.deleted_at IS NULL
+`
+	departementSelect = departementColumns + departementFrom
+)
+
+var departementKeyset = utils.Keyset{ID: "d.id", Default: utils.SortField{Key: "createdAt", Order: "asc"}}
+
+type sqlDepartementRepository struct {
+	q Querier
+	d dialect.Dialect
+}
+
+// scanDepartement reads a departementSelect row and fills in the clock rules
+// and timezone it inherits from the tree.
+func scanDepartement(row rowScanner, tree model.DepartementTree) (model.Departement, error) {
+	var d model.Departement
+	err := row.Scan(
+		&d.ID, &d.ParentID, &d.HeadEmployeeID, &d.HeadEmployeeName, &d.DepartementName, &d.Version,
+		&d.CreatedAt, &d.CreatedBy, &d.UpdatedAt, &d.UpdatedBy,
+		&d.DeletedAt, &d.DeletedBy,
+	)
+	if err != nil {
+		return d, err
+	}
+
+	maxIn, maxOut := tree.ClockRules(d.ID)
+	d.MaxClockInTime, _ = time.Parse("15:04:05", maxIn)
+	d.MaxClockOutTime, _ = time.Parse("15:04:05", maxOut)
+	d.Timezone = tree.Timezone(d.ID)
+	if node, ok := tree[d.ID]; ok {
+		d.ClockInInherited = node.MaxClockInTime == nil
+		d.ClockOutInherited = node.MaxClockOutTime == nil
+		d.TimezoneInherited = node.Timezone == nil
+	}
+	return d, nil
+}
+
+func (r *sqlDepartementRepository) Tree(ctx context.Context) (model.DepartementTree, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, parent_id, head_employee_id, departement_name, max_clock_in_time, max_clock_out_time, timezone
+		FROM departement
+		WHERE deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tree := model.DepartementTree{}
+	for rows.Next() {
+		var n model.DepartementNode
+		if err := rows.Scan(&n.ID, &n.ParentID, &n.HeadEmployeeID, &n.DepartementName, &n.MaxClockInTime, &n.MaxClockOutTime, &n.Timezone); err != nil {
+			return nil, err
+		}
+		tree[n.ID] = &n
+	}
+	return tree, rows.Err()
+}
+
+// scanAll reads departementSelect rows, through page when the query is a
+// page of a listing.
+func (r *sqlDepartementRepository) scanAll(ctx context.Context, page *utils.Page, query string, args ...interface{}) ([]model.Departement, error) {
+	tree, err := r.Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var it rowIterator = rows
+	if page != nil {
+		it = page.Rows(rows)
+	}
+
+	var result []model.Departement
+	for it.Next() {
+		d, err := scanDepartement(it, tree)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, it.Err()
+}
+
+func (r *sqlDepartementRepository) List(ctx context.Context, params utils.QueryParams) ([]model.Departement, utils.PageInfo, error) {
+	page, err := utils.BuildPage(params, departementFields, departementKeyset)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	filterSQL, filterArgs, err := utils.BuildFilterSQL(params.Filter, departementFields, params.Location)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+
+	query, args := page.Limit(fmt.Sprintf(`
+		%s
+		%s
+		%s
+		WHERE d.deleted_at IS NULL
+		%s
+		%s
+		%s
+	`, departementColumns, page.Select, departementFrom, filterSQL, page.Where, page.OrderBy), append(append([]interface{}{}, filterArgs...), page.Args...))
+
+	result, err := r.scanAll(ctx, page, query, args...)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	result = result[:page.Keep(len(result))]
+
+	var total int
+	if page.Counted() {
+		err = r.q.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COUNT(*) FROM departement d
+			WHERE d.deleted_at IS NULL
+			%s
+		`, filterSQL), filterArgs...).Scan(&total)
+	}
+	return result, page.Info(total), err
+}
+
+func (r *sqlDepartementRepository) All(ctx context.Context) ([]model.Departement, error) {
+	return r.scanAll(ctx, nil, departementSelect+`
+		WHERE d.deleted_at IS NULL
+		ORDER BY d.departement_name ASC
+	`)
+}
+
+func (r *sqlDepartementRepository) GetByID(ctx context.Context, id string) (model.Departement, error) {
+	result, err := r.scanAll(ctx, nil, departementSelect+`
+		WHERE d.id = ? AND d.deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return model.Departement{}, err
+	}
+	if len(result) == 0 {
+		return model.Departement{}, ErrNotFound
+	}
+	return result[0], nil
+}
+
+func (r *sqlDepartementRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return exists(ctx, r.q, `SELECT 1 FROM departement WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+func (r *sqlDepartementRepository) IDsByName(ctx context.Context) (map[string]string, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, departement_name FROM departement WHERE deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]string{}
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		result[strings.ToLower(strings.TrimSpace(name))] = id
+	}
+	return result, rows.Err()
+}
+
+func (r *sqlDepartementRepository) NameTaken(ctx context.Context, name string, excludeID string) (bool, error) {
+	return exists(ctx, r.q, `
+		SELECT 1 FROM departement
+		WHERE LOWER(departement_name) = LOWER(?) AND id <> ? AND deleted_at IS NULL
+	`, name, excludeID)
+}
+
+func (r *sqlDepartementRepository) HasChildren(ctx context.Context, id string) (bool, error) {
+	ids, err := selectIDs(ctx, r.q, `
+		SELECT id FROM departement WHERE parent_id = ? AND deleted_at IS NULL `+r.d.ForUpdate(),
+		id)
+	return len(ids) > 0, err
+}
+
+func (r *sqlDepartementRepository) Create(ctx context.Context, d model.DepartementNode, actor string, now time.Time) error {
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO departement (id, parent_id, head_employee_id, departement_name, max_clock_in_time, max_clock_out_time, timezone, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.ParentID, d.HeadEmployeeID, d.DepartementName,
+		d.MaxClockInTime, d.MaxClockOutTime, d.Timezone, now, actor)
+	return err
+}
+
+func (r *sqlDepartementRepository) Update(ctx context.Context, id string, fields map[string]interface{}, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error) {
+	return auditedUpdate(ctx, r.q, "departement", id, fields, departementUpdateFields, ifVersion, actor, now)
+}
+
+func (r *sqlDepartementRepository) SoftDelete(ctx context.Context, id string, ifVersion int, actor string, now time.Time) (bool, error) {
+	return softDelete(ctx, r.q, "departement", id, ifVersion, actor, now)
+}
+
+func (r *sqlDepartementRepository) ListDeleted(ctx context.Context, pagination utils.Pagination) ([]model.Departement, int, error) {
+	query, args := limitSQL(`
+		SELECT id, parent_id, departement_name, max_clock_in_time, max_clock_out_time,
+		       created_at, created_by, deleted_at, deleted_by
+		FROM departement
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`, nil, pagination)
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	result := []model.Departement{}
+	for rows.Next() {
+		var (
+			d           model.Departement
+			clockInRaw  sql.NullString
+			clockOutRaw sql.NullString
+		)
+		err := rows.Scan(
+			&d.ID, &d.ParentID, &d.DepartementName, &clockInRaw, &clockOutRaw,
+			&d.CreatedAt, &d.CreatedBy, &d.DeletedAt, &d.DeletedBy,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.MaxClockInTime, _ = time.Parse("15:04:05", clockInRaw.String)
+		d.MaxClockOutTime, _ = time.Parse("15:04:05", clockOutRaw.String)
+		d.ClockInInherited = !clockInRaw.Valid
+		d.ClockOutInherited = !clockOutRaw.Valid
+		result = append(result, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM departement WHERE deleted_at IS NOT NULL`).Scan(&total)
+	return result, total, err
+}
+
+func (r *sqlDepartementRepository) GetDeleted(ctx context.Context, id string) (model.Departement, error) {
+	var d model.Departement
+	err := r.q.QueryRowContext(ctx, `
+		SELECT id, parent_id, departement_name, deleted_at, deleted_by
+		FROM departement
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`, id).Scan(&d.ID, &d.ParentID, &d.DepartementName, &d.DeletedAt, &d.DeletedBy)
+	if err == sql.ErrNoRows {
+		return d, ErrNotFound
+	}
+	return d, err
+}
+
+func (r *sqlDepartementRepository) Restore(ctx context.Context, id string, actor string, now time.Time) error {
+	return restore(ctx, r.q, "departement", id, actor, now)
+}
+
+func (r *sqlDepartementRepository) ClearDeletedHeads(ctx context.Context, cutoff time.Time, actor string, now time.Time) (map[string]string, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT d.id, d.head_employee_id FROM departement d
+		JOIN employee e ON e.id = d.head_employee_id
+		WHERE e.deleted_at IS NOT NULL AND e.deleted_at < ? `+r.d.ForUpdate(),
+		cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heads := map[string]string{}
+	var ids []interface{}
+	for rows.Next() {
+		var id, head string
+		if err := rows.Scan(&id, &head); err != nil {
+			return nil, err
+		}
+		heads[id] = head
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return heads, err
+	}
+
+	args := append([]interface{}{now, actor}, ids...)
+	_, err = r.q.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE departement
+		SET head_employee_id = NULL, version = version + 1, updated_at = ?, updated_by = ?
+		WHERE id IN (%s)
+	`, utils.Placeholders(len(ids))), args...)
+	return heads, err
+}
+
+func (r *sqlDepartementRepository) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	purged := map[string]int64{}
+
+	// Departements still referenced by an employee, an employee event or
+	// another departement are skipped; a later run can purge them.
+	ids, err := selectIDs(ctx, r.q, `
+		SELECT d.id FROM departement d
+		WHERE d.deleted_at IS NOT NULL AND d.deleted_at < ?
+		AND NOT EXISTS (SELECT 1 FROM employee e WHERE e.departement_id = d.id)
+		AND NOT EXISTS (SELECT 1 FROM departement c WHERE c.parent_id = d.id)
+		AND NOT EXISTS (
+			SELECT 1 FROM employee_event ev
+			WHERE ev.from_departement_id = d.id OR ev.to_departement_id = d.id
+		)
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates int64
+	if err := r.q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM departement WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	`, cutoff).Scan(&candidates); err != nil {
+		return nil, err
+	}
+	purged["departementSkipped"] = candidates - int64(len(ids))
+
+	if len(ids) == 0 {
+		return purged, nil
+	}
+
+	n, err := execCount(ctx, r.q, fmt.Sprintf(`DELETE FROM departement WHERE id IN (%s)`, utils.Placeholders(len(ids))), ids...)
+	purged["departement"] = n
+	return purged, err
+}
@@ -0,0 +1,392 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/utils"
+)
+
+var employeeFields = utils.Fields{
+	"employeeID":      {Column: "e.employee_id"},
+	"departementID":   {Column: "e.departement_id"},
+	"departementName": {Column: "d.departement_name"},
+	// Misspelled key accepted by earlier releases
+	"departmentName": {Column: "d.departement_name"},
+	"name":           {Column: "e.name"},
+	"address":        {Column: "e.address"},
+	"position":       {Column: "e.position", Nullable: true},
+	"status": {Column: "e.status", Type: utils.EnumField, Values: map[string]interface{}{
+		model.EmployeeStatusActive:     model.EmployeeStatusActive,
+		model.EmployeeStatusResigned:   model.EmployeeStatusResigned,
+		model.EmployeeStatusTerminated: model.EmployeeStatusTerminated,
+	}},
+	"role": {Column: "e.role", Type: utils.EnumField, Values: map[string]interface{}{
+		model.EmployeeRoleEmployee: model.EmployeeRoleEmployee,
+		model.EmployeeRoleAdmin:    model.EmployeeRoleAdmin,
+	}},
+	"createdAt": {Column: "e.created_at", Type: utils.DateField},
+}
+
+var employeeUpdateFields = []string{"name", "departement_id", "address", "position", "status"}
+
+type sqlEmployeeRepository struct {
+	q Querier
+	d dialect.Dialect
+}
+
+var employeeKeyset = utils.Keyset{ID: "e.id", Default: utils.SortField{Key: "createdAt", Order: "asc"}}
+
+func (r *sqlEmployeeRepository) List(ctx context.Context, params utils.QueryParams) ([]model.Employee, utils.PageInfo, error) {
+	page, err := utils.BuildPage(params, employeeFields, employeeKeyset)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	filterSQL, filterArgs, err := utils.BuildFilterSQL(params.Filter, employeeFields, params.Location)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+
+	query, args := page.Limit(fmt.Sprintf(`
+		SELECT 
+		e.id, 
+		e.employee_id,
+		e.departement_id, 
+		d.departement_name, 
+		e.name, 
+		e.address,
+		e.position,
+		e.status,
+		e.version
+		%s
+		FROM employee e
+		JOIN departement d ON e.departement_id = d.id
+		WHERE e.deleted_at IS NULL
+		%s
+		%s
+		%s
+	`, page.Select, filterSQL, page.Where, page.OrderBy), append(append([]interface{}{}, filterArgs...), page.Args...))
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	defer rows.Close()
+
+	var result []model.Employee
+	pageRows := page.Rows(rows)
+	for pageRows.Next() {
+		var e model.Employee
+		err := pageRows.Scan(
+			&e.ID, &e.EmployeeID, &e.DepartementID,
+			&e.DepartementName,
+			&e.Name, &e.Address,
+			&e.Position, &e.Status, &e.Version,
+		)
+		if err != nil {
+			return nil, utils.PageInfo{}, err
+		}
+		result = append(result, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	result = result[:page.Keep(len(result))]
+
+	var total int
+	if page.Counted() {
+		err = r.q.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT COUNT(*)
+			FROM employee e
+			JOIN departement d ON e.departement_id = d.id
+			WHERE e.deleted_at IS NULL
+			%s
+		`, filterSQL), filterArgs...).Scan(&total)
+	}
+	return result, page.Info(total), err
+}
+
+func (r *sqlEmployeeRepository) GetByID(ctx context.Context, id string) (model.Employee, error) {
+	var e model.Employee
+	err := r.q.QueryRowContext(ctx, `
+		SELECT e.id, e.employee_id, e.departement_id, d.departement_name, e.name, e.address, e.position, e.status, e.role, e.version,
+		       e.created_at, e.created_by, e.updated_at, e.updated_by
+		FROM employee e
+		JOIN departement d ON e.departement_id = d.id
+		WHERE e.id = ? AND e.deleted_at IS NULL
+	`, id).Scan(
+		&e.ID, &e.EmployeeID, &e.DepartementID, &e.DepartementName, &e.Name, &e.Address, &e.Position, &e.Status, &e.Role, &e.Version,
+		&e.CreatedAt, &e.CreatedBy, &e.UpdatedAt, &e.UpdatedBy,
+	)
+	if err == sql.ErrNoRows {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+func (r *sqlEmployeeRepository) GetByEmployeeID(ctx context.Context, employeeID string) (model.Employee, error) {
+	var e model.Employee
+	err := r.q.QueryRowContext(ctx, `
+		SELECT id, employee_id, departement_id, name, address, password, status, role, version,
+		       created_at, created_by, updated_at, updated_by, deleted_at, deleted_by
+		FROM employee
+		WHERE employee_id = ? AND deleted_at IS NULL
+	`, employeeID).Scan(
+		&e.ID, &e.EmployeeID, &e.DepartementID, &e.Name, &e.Address, &e.Password, &e.Status, &e.Role, &e.Version,
+		&e.CreatedAt, &e.CreatedBy, &e.UpdatedAt, &e.UpdatedBy,
+		&e.DeletedAt, &e.DeletedBy,
+	)
+	if err == sql.ErrNoRows {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+func (r *sqlEmployeeRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return exists(ctx, r.q, `SELECT 1 FROM employee WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+func (r *sqlEmployeeRepository) EmployeeIDTaken(ctx context.Context, employeeID string) (bool, error) {
+	return exists(ctx, r.q, `SELECT 1 FROM employee WHERE employee_id = ?`, employeeID)
+}
+
+func (r *sqlEmployeeRepository) EmployeeIDsInUse(ctx context.Context) (map[string]bool, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT employee_id, deleted_at IS NOT NULL FROM employee
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]bool{}
+	for rows.Next() {
+		var (
+			employeeID string
+			deleted    bool
+		)
+		if err := rows.Scan(&employeeID, &deleted); err != nil {
+			return nil, err
+		}
+		result[strings.ToLower(employeeID)] = deleted
+	}
+	return result, rows.Err()
+}
+
+func (r *sqlEmployeeRepository) IDsInDepartement(ctx context.Context, departementID string) ([]string, error) {
+	ids, err := selectIDs(ctx, r.q, `
+		SELECT id FROM employee WHERE departement_id = ? AND deleted_at IS NULL `+r.d.ForUpdate(),
+		departementID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.(string))
+	}
+	return result, nil
+}
+
+func (r *sqlEmployeeRepository) ListByIDs(ctx context.Context, ids []string) ([]model.Employee, error) {
+	query := `
+		SELECT e.id, e.employee_id, e.departement_id, d.departement_name, e.name, e.address, e.position, e.status, e.version
+		FROM employee e
+		JOIN departement d ON e.departement_id = d.id
+		WHERE e.deleted_at IS NULL`
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	if len(ids) > 0 {
+		query += fmt.Sprintf(" AND e.id IN (%s)", utils.Placeholders(len(ids)))
+	}
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.Employee
+	for rows.Next() {
+		var e model.Employee
+		if err := rows.Scan(&e.ID, &e.EmployeeID, &e.DepartementID, &e.DepartementName, &e.Name, &e.Address, &e.Position, &e.Status, &e.Version); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+func (r *sqlEmployeeRepository) Create(ctx context.Context, e model.Employee) error {
+	if e.Role == "" {
+		e.Role = model.EmployeeRoleEmployee
+	}
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO employee (id, employee_id, departement_id, name, address, position, status, role, password, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.ID, e.EmployeeID, e.DepartementID, e.Name, e.Address, e.Position,
+		e.Status, e.Role, e.Password, e.CreatedAt, e.CreatedBy)
+	return err
+}
+
+func (r *sqlEmployeeRepository) Update(ctx context.Context, id string, fields map[string]interface{}, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error) {
+	return auditedUpdate(ctx, r.q, "employee", id, fields, employeeUpdateFields, ifVersion, actor, now)
+}
+
+func (r *sqlEmployeeRepository) SetPassword(ctx context.Context, id string, hash string, actor string, now time.Time) error {
+	res, err := r.q.ExecContext(ctx, `
+		UPDATE employee SET password = ?, updated_at = ?, updated_by = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL
+	`, hash, now, actor, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlEmployeeRepository) SoftDelete(ctx context.Context, id string, ifVersion int, actor string, now time.Time) (bool, error) {
+	return softDelete(ctx, r.q, "employee", id, ifVersion, actor, now)
+}
+
+func (r *sqlEmployeeRepository) ListDeleted(ctx context.Context, pagination utils.Pagination) ([]model.Employee, int, error) {
+	query, args := limitSQL(`
+		SELECT e.id, e.employee_id, e.departement_id, COALESCE(d.departement_name, ''),
+		       e.name, e.address, e.position, e.status,
+		       e.created_at, e.created_by, e.deleted_at, e.deleted_by
+		FROM employee e
+		LEFT JOIN departement d ON e.departement_id = d.id
+		WHERE e.deleted_at IS NOT NULL
+		ORDER BY e.deleted_at DESC
+	`, nil, pagination)
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	result := []model.Employee{}
+	for rows.Next() {
+		var e model.Employee
+		err := rows.Scan(
+			&e.ID, &e.EmployeeID, &e.DepartementID, &e.DepartementName,
+			&e.Name, &e.Address, &e.Position, &e.Status,
+			&e.CreatedAt, &e.CreatedBy, &e.DeletedAt, &e.DeletedBy,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM employee WHERE deleted_at IS NOT NULL`).Scan(&total)
+	return result, total, err
+}
+
+func (r *sqlEmployeeRepository) GetDeleted(ctx context.Context, id string) (model.Employee, error) {
+	var e model.Employee
+	err := r.q.QueryRowContext(ctx, `
+		SELECT id, employee_id, departement_id, name, deleted_at, deleted_by
+		FROM employee
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`, id).Scan(&e.ID, &e.EmployeeID, &e.DepartementID, &e.Name, &e.DeletedAt, &e.DeletedBy)
+	if err == sql.ErrNoRows {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+func (r *sqlEmployeeRepository) Restore(ctx context.Context, id string, actor string, now time.Time) error {
+	return restore(ctx, r.q, "employee", id, actor, now)
+}
+
+func (r *sqlEmployeeRepository) Purge(ctx context.Context, cutoff time.Time) (map[string]int64, error) {
+	purged := map[string]int64{}
+
+	ids, err := selectIDs(ctx, r.q, `
+		SELECT id FROM employee WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	`, cutoff)
+	if err != nil || len(ids) == 0 {
+		return purged, err
+	}
+
+	in := utils.Placeholders(len(ids))
+	steps := []struct {
+		key   string
+		query string
+	}{
+		{"attendanceHistory", fmt.Sprintf(`DELETE FROM attendance_history WHERE employee_id IN (SELECT employee_id FROM employee WHERE id IN (%s))`, in)},
+		{"attendance", fmt.Sprintf(`DELETE FROM attendance WHERE employee_id IN (SELECT employee_id FROM employee WHERE id IN (%s))`, in)},
+		{"employeeEvent", fmt.Sprintf(`DELETE FROM employee_event WHERE employee_id IN (%s)`, in)},
+		{"employee", fmt.Sprintf(`DELETE FROM employee WHERE id IN (%s)`, in)},
+	}
+	for _, step := range steps {
+		n, err := execCount(ctx, r.q, step.query, ids...)
+		if err != nil {
+			return nil, err
+		}
+		purged[step.key] += n
+	}
+	return purged, nil
+}
+
+func (r *sqlEmployeeRepository) ListEvents(ctx context.Context, id string) ([]model.EmployeeEvent, error) {
+	rows, err := r.q.QueryContext(ctx, `
+		SELECT id, employee_id, event_type, effective_date,
+		       from_departement_id, to_departement_id, from_position, to_position, reason,
+		       created_at, created_by
+		FROM employee_event
+		WHERE employee_id = ? AND deleted_at IS NULL
+		ORDER BY effective_date ASC, created_at ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []model.EmployeeEvent{}
+	for rows.Next() {
+		var ev model.EmployeeEvent
+		err := rows.Scan(
+			&ev.ID, &ev.EmployeeID, &ev.EventType, &ev.EffectiveDate,
+			&ev.FromDepartementID, &ev.ToDepartementID, &ev.FromPosition, &ev.ToPosition, &ev.Reason,
+			&ev.CreatedAt, &ev.CreatedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ev)
+	}
+	return result, rows.Err()
+}
+
+func (r *sqlEmployeeRepository) CreateEvent(ctx context.Context, ev model.EmployeeEvent) error {
+	if ev.ID == "" {
+		ev.ID = utils.GenerateID()
+	}
+	_, err := r.q.ExecContext(ctx, `
+		INSERT INTO employee_event (id, employee_id, event_type, effective_date,
+			from_departement_id, to_departement_id, from_position, to_position, reason,
+			created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ev.ID, ev.EmployeeID, ev.EventType, ev.EffectiveDate.Format("2006-01-02"),
+		ev.FromDepartementID, ev.ToDepartementID, ev.FromPosition, ev.ToPosition, ev.Reason,
+		ev.CreatedAt, ev.CreatedBy)
+	return err
+}
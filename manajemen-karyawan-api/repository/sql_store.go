@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/utils"
+)
+
+type sqlStore struct {
+	db  *sql.DB
+	tx  *sql.Tx
+	d   dialect.Dialect
+	loc *time.Location
+}
+
+// NewStore returns a Store backed by a connection pool of the given dialect.
+// loc is the business timezone that decides which day an attendance belongs
+// to.
+func NewStore(db *sql.DB, d dialect.Dialect, loc *time.Location) Store {
+	return &sqlStore{db: db, d: d, loc: loc}
+}
+
+// querier returns the transaction or the pool, rebinding every query to the
+// store's dialect.
+func (s *sqlStore) querier() Querier {
+	if s.tx != nil {
+		return boundQuerier{q: s.tx, d: s.d}
+	}
+	return boundQuerier{q: s.db, d: s.d}
+}
+
+func (s *sqlStore) Employees() EmployeeRepository {
+	return &sqlEmployeeRepository{q: s.querier(), d: s.d}
+}
+
+func (s *sqlStore) Departements() DepartementRepository {
+	return &sqlDepartementRepository{q: s.querier(), d: s.d}
+}
+
+func (s *sqlStore) Attendance() AttendanceRepository {
+	return &sqlAttendanceRepository{q: s.querier(), d: s.d, loc: s.loc}
+}
+
+func (s *sqlStore) Audit() AuditRepository {
+	return &sqlAuditRepository{q: s.querier()}
+}
+
+func (s *sqlStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	// Already inside a transaction, join it
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&sqlStore{db: s.db, tx: tx, d: s.d, loc: s.loc}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// boundQuerier lets repositories write every query with "?" placeholders.
+type boundQuerier struct {
+	q Querier
+	d dialect.Dialect
+}
+
+func (b boundQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return b.q.ExecContext(ctx, b.d.Rebind(query), b.d.BindArgs(args)...)
+}
+
+func (b boundQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return b.q.QueryContext(ctx, b.d.Rebind(query), b.d.BindArgs(args)...)
+}
+
+func (b boundQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return b.q.QueryRowContext(ctx, b.d.Rebind(query), b.d.BindArgs(args)...)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// rowIterator is satisfied by *sql.Rows and by utils.PageRows.
+type rowIterator interface {
+	rowScanner
+	Next() bool
+	Err() error
+}
+
+func execCount(ctx context.Context, q Querier, query string, args ...interface{}) (int64, error) {
+	res, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func selectIDs(ctx context.Context, q Querier, query string, args ...interface{}) ([]interface{}, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []interface{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func exists(ctx context.Context, q Querier, query string, args ...interface{}) (bool, error) {
+	found := false
+	err := q.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (%s)", query), args...).Scan(&found)
+	return found, err
+}
+
+// snapshotRow reads the current values of columns for a single active row.
+func snapshotRow(ctx context.Context, q Querier, table string, id string, columns []string) (map[string]interface{}, error) {
+	if len(columns) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND deleted_at IS NULL", strings.Join(columns, ", "), table)
+	if err := q.QueryRowContext(ctx, query, id).Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	row := map[string]interface{}{}
+	for i, col := range columns {
+		row[col] = NormalizeAuditValue(values[i])
+	}
+	return row, nil
+}
+
+// rowVersion returns the version of a single active row.
+func rowVersion(ctx context.Context, q Querier, table string, id string) (int, error) {
+	var version int
+	err := q.QueryRowContext(ctx, fmt.Sprintf("SELECT version FROM %s WHERE id = ? AND deleted_at IS NULL", table), id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return version, err
+}
+
+// versionMiss explains why a version-guarded write matched no row: the row
+// is gone, or it has moved on to another version.
+func versionMiss(ctx context.Context, q Querier, table string, id string) error {
+	found, err := exists(ctx, q, fmt.Sprintf("SELECT 1 FROM %s WHERE id = ? AND deleted_at IS NULL", table), id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// auditedUpdate updates the whitelisted fields of table/id and returns the old
+// and new value of every field that actually changed, plus the new version.
+// The write is guarded by the version the old values were read at, so the
+// diff always describes exactly what was overwritten.
+func auditedUpdate(ctx context.Context, q Querier, table string, id string, fields map[string]interface{}, whitelist []string, ifVersion int, actor string, now time.Time) (map[string]model.AuditChange, int, error) {
+	current, err := rowVersion(ctx, q, table, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if ifVersion != 0 && ifVersion != current {
+		return nil, 0, ErrVersionConflict
+	}
+
+	audit := map[string]interface{}{
+		"updated_at": now,
+		"updated_by": actor,
+		"version":    current + 1,
+	}
+
+	query, args, err := utils.BuildDynamicUpdateQuery(table, fields, whitelist, audit)
+	if err != nil {
+		return nil, 0, err
+	}
+	query += " AND version = ?"
+	args = append(args, id, current)
+
+	columns := make([]string, 0, len(fields))
+	for key := range fields {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	old, err := snapshotRow(ctx, q, table, id, columns)
+	if err == sql.ErrNoRows {
+		return nil, 0, ErrNotFound
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	n, err := execCount(ctx, q, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return nil, 0, versionMiss(ctx, q, table, id)
+	}
+	return DiffValues(old, fields), current + 1, nil
+}
+
+func softDelete(ctx context.Context, q Querier, table string, id string, ifVersion int, actor string, now time.Time) (bool, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET deleted_at = ?, deleted_by = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL
+	`, table)
+	args := []interface{}{now, actor, id}
+	if ifVersion != 0 {
+		query += " AND version = ?"
+		args = append(args, ifVersion)
+	}
+
+	n, err := execCount(ctx, q, query, args...)
+	if err != nil || n > 0 || ifVersion == 0 {
+		return n > 0, err
+	}
+	if err := versionMiss(ctx, q, table, id); err != ErrNotFound {
+		return false, err
+	}
+	return false, nil
+}
+
+func restore(ctx context.Context, q Querier, table string, id string, actor string, now time.Time) error {
+	n, err := execCount(ctx, q, fmt.Sprintf(`
+		UPDATE %s
+		SET deleted_at = NULL, deleted_by = NULL, updated_at = ?, updated_by = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`, table), now, actor, id)
+	if err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// limitSQL appends LIMIT/OFFSET to query when pagination is requested.
+func limitSQL(query string, args []interface{}, pagination utils.Pagination) (string, []interface{}) {
+	if !pagination.Use {
+		return query, args
+	}
+	return query + " LIMIT ? OFFSET ?", append(args, pagination.Limit, pagination.Offset)
+}
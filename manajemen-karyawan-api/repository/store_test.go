@@ -0,0 +1,236 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/dialect"
+	"manajemen-karyawan-api/migrations"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/repository/memstore"
+
+	_ "modernc.org/sqlite"
+)
+
+// stores returns the implementations every contract test runs against: the
+// in-memory fake used by the handler tests and the SQL store over SQLite.
+func stores(t *testing.T) map[string]repository.Store {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrations.New(db, dialect.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]repository.Store{
+		"memstore": memstore.New(time.UTC),
+		"sqlite":   repository.NewStore(db, dialect.SQLite, time.UTC),
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+// seed creates departement "dep-it" and employee "e1" with code "EMP001".
+func seed(t *testing.T, store repository.Store) {
+	t.Helper()
+	ctx := context.Background()
+	node := model.DepartementNode{
+		ID:              "dep-it",
+		DepartementName: "IT",
+		MaxClockInTime:  strPtr("08:00:00"),
+		MaxClockOutTime: strPtr("17:00:00"),
+	}
+	if err := store.Departements().Create(ctx, node, "test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	e := model.Employee{
+		ID:            "e1",
+		EmployeeID:    "EMP001",
+		DepartementID: "dep-it",
+		Name:          "Budi",
+		Status:        model.EmployeeStatusActive,
+		Password:      "hash",
+	}
+	e.CreatedAt = time.Now()
+	e.CreatedBy = "test"
+	if err := store.Employees().Create(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmployeeVersioning(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, store)
+			employees := store.Employees()
+
+			changes, version, err := employees.Update(ctx, "e1", map[string]interface{}{"name": "Budi S", "address": ""}, 1, "test", time.Now())
+			if err != nil || version != 2 {
+				t.Fatalf("Update = %d, %v", version, err)
+			}
+			if len(changes) != 1 || changes["name"].Old != "Budi" || changes["name"].New != "Budi S" {
+				t.Errorf("changes = %+v", changes)
+			}
+
+			if _, _, err := employees.Update(ctx, "e1", map[string]interface{}{"name": "x"}, 1, "test", time.Now()); !errors.Is(err, repository.ErrVersionConflict) {
+				t.Errorf("stale Update = %v", err)
+			}
+			if _, _, err := employees.Update(ctx, "e1", map[string]interface{}{"password": "x"}, 0, "test", time.Now()); err == nil {
+				t.Error("Update of a field outside the whitelist succeeded")
+			}
+
+			if ok, err := employees.SoftDelete(ctx, "e1", 1, "test", time.Now()); ok || !errors.Is(err, repository.ErrVersionConflict) {
+				t.Errorf("stale SoftDelete = %v, %v", ok, err)
+			}
+			if ok, err := employees.SoftDelete(ctx, "e1", 2, "test", time.Now()); !ok || err != nil {
+				t.Fatalf("SoftDelete = %v, %v", ok, err)
+			}
+			if _, err := employees.GetByID(ctx, "e1"); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("GetByID of a deleted employee = %v", err)
+			}
+			if taken, _ := employees.EmployeeIDTaken(ctx, "EMP001"); !taken {
+				t.Error("the code of a deleted employee is free")
+			}
+
+			if err := employees.Restore(ctx, "e1", "test", time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			e, err := employees.GetByID(ctx, "e1")
+			if err != nil || e.Name != "Budi S" || e.Version != 4 {
+				t.Errorf("restored employee = %+v, %v", e, err)
+			}
+		})
+	}
+}
+
+func TestDepartementNames(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, store)
+			departements := store.Departements()
+
+			if taken, err := departements.NameTaken(ctx, "it", ""); !taken || err != nil {
+				t.Errorf("NameTaken(it) = %v, %v", taken, err)
+			}
+			if taken, _ := departements.NameTaken(ctx, "IT", "dep-it"); taken {
+				t.Error("a departement takes its own name")
+			}
+			if ids, _ := departements.IDsByName(ctx); ids["it"] != "dep-it" {
+				t.Errorf("IDsByName = %v", ids)
+			}
+		})
+	}
+}
+
+func TestClockInTransaction(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, store)
+			clockIn := time.Date(2024, 3, 11, 1, 0, 0, 0, time.UTC)
+			dayStart, dayEnd := clockIn.Truncate(24*time.Hour), clockIn.Truncate(24*time.Hour).Add(24*time.Hour)
+
+			// A failed transaction leaves nothing behind
+			errRollback := errors.New("rollback")
+			err := store.WithTx(ctx, func(tx repository.Store) error {
+				if err := tx.Attendance().LockEmployee(ctx, "EMP001"); err != nil {
+					return err
+				}
+				a := model.Attendance{ID: "a1", EmployeeID: "EMP001", ClockIn: &clockIn}
+				if err := tx.Attendance().Create(ctx, a); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			if !errors.Is(err, errRollback) {
+				t.Fatalf("WithTx = %v", err)
+			}
+			if _, err := store.Attendance().FindByDay(ctx, "EMP001", dayStart, dayEnd); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("FindByDay after rollback = %v", err)
+			}
+
+			err = store.WithTx(ctx, func(tx repository.Store) error {
+				if err := tx.Attendance().LockEmployee(ctx, "EMP001"); err != nil {
+					return err
+				}
+				a := model.Attendance{ID: "a1", EmployeeID: "EMP001", ClockIn: &clockIn}
+				if err := tx.Attendance().Create(ctx, a); err != nil {
+					return err
+				}
+				h := model.AttendanceHistory{EmployeeID: "EMP001", AttendanceID: "a1", DateAttendance: clockIn, AttendanceType: 1, Description: "WFO"}
+				return tx.Attendance().AddHistory(ctx, h)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := store.Attendance().FindByDay(ctx, "EMP001", dayStart, dayEnd)
+			if err != nil || a.ID != "a1" || a.ClockOut != nil {
+				t.Errorf("FindByDay = %+v, %v", a, err)
+			}
+
+			logs, err := store.Attendance().LogsBetween(ctx, dayStart, dayEnd)
+			if err != nil || len(logs) != 1 || logs[0].DepartementID != "dep-it" || logs[0].EmployeeName != "Budi" {
+				t.Errorf("LogsBetween = %+v, %v", logs, err)
+			}
+		})
+	}
+}
+
+func TestPurgeReleasesHeads(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, store)
+			if _, _, err := store.Departements().Update(ctx, "dep-it", map[string]interface{}{"head_employee_id": "e1"}, 0, "test", time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Employees().SoftDelete(ctx, "e1", 0, "test", time.Now().AddDate(-2, 0, 0)); err != nil {
+				t.Fatal(err)
+			}
+			cutoff := time.Now().AddDate(-1, 0, 0)
+
+			err := store.WithTx(ctx, func(tx repository.Store) error {
+				heads, err := tx.Departements().ClearDeletedHeads(ctx, cutoff, "admin", time.Now())
+				if err != nil {
+					return err
+				}
+				if len(heads) != 1 || heads["dep-it"] != "e1" {
+					t.Errorf("ClearDeletedHeads = %v", heads)
+				}
+				purged, err := tx.Employees().Purge(ctx, cutoff)
+				if purged["employee"] != 1 {
+					t.Errorf("Purge = %v", purged)
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d, err := store.Departements().GetByID(ctx, "dep-it")
+			if err != nil || d.HeadEmployeeID != nil || d.Version != 3 {
+				t.Errorf("departement after purge = %+v, %v", d, err)
+			}
+			if _, err := store.Employees().GetDeleted(ctx, "e1"); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("GetDeleted after purge = %v", err)
+			}
+		})
+	}
+}
@@ -1,64 +1,238 @@
 package routes
 
 import (
+	"net/http"
+
+	"manajemen-karyawan-api/config"
 	"manajemen-karyawan-api/controller"
+	"manajemen-karyawan-api/metrics"
 	"manajemen-karyawan-api/middleware"
+	"manajemen-karyawan-api/migrations"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/service/attendance"
+	"manajemen-karyawan-api/service/search"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func RegisterRoutes(r *gin.Engine) {
+func RegisterRoutes(r *gin.Engine, store repository.Store, index *search.Index, cfg *config.Config) error {
+	d, err := cfg.Dialect()
+	if err != nil {
+		return err
+	}
+	migrator, err := migrations.New(config.DB, d)
+	if err != nil {
+		return err
+	}
+
+	healthController := controller.NewHealthController(config.DB, migrator)
+	authController := controller.NewAuthController(store, cfg.Auth)
+	employeeController := controller.NewEmployeeController(store, index, cfg.Employee.DefaultPassword, cfg.Location())
+	departementController := controller.NewDepartementController(store, index, cfg.Location())
+	attendanceController := controller.NewAttendanceController(attendance.NewService(store, cfg.Location()))
+	adminController := controller.NewAdminController(store, cfg.Retention, cfg.Location())
+	searchController := controller.NewSearchController(index)
+	authMiddleware := middleware.AuthMiddleware([]byte(cfg.Auth.JWTSecret))
+
+	// ClientIP (recorded in the audit log) only believes X-Forwarded-For
+	// from the configured proxies; with none it is the connection address.
+	var proxies []string
+	if len(cfg.App.TrustedProxies) > 0 {
+		proxies = cfg.App.TrustedProxies
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+
+	// Prometheus metrics (public, like the probes below). Registered
+	// first so requests rejected by later middleware are counted too.
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterDB(config.DB, cfg.DB.Name); err != nil {
+			return err
+		}
+		r.Use(middleware.MetricsMiddleware())
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	// Tracing: continues a W3C traceparent from the caller or starts a new
+	// trace. Probes and scrapes would only add noise.
+	if cfg.Tracing.Enabled {
+		r.Use(otelgin.Middleware(cfg.Tracing.ServiceName,
+			otelgin.WithFilter(func(req *http.Request) bool {
+				switch req.URL.Path {
+				case "/healthz", "/readyz", "/metrics":
+					return false
+				}
+				return true
+			}),
+		))
+	}
+
+	// Request ID first so the access log and every record logged with the
+	// request context carry it
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(middleware.AccessLogMiddleware())
+	r.Use(middleware.RecoveryMiddleware())
+	// Renders errors attached with c.Error as problem+json
+	r.Use(middleware.ErrorMiddleware())
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(middleware.NoRoute)
+	r.NoMethod(middleware.NoMethod)
+
 	// Apply CORS globally
-	r.Use(middleware.CORSMiddleware())
+	r.Use(middleware.CORSMiddleware(cfg.CORS.AllowOrigins))
 
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Probes and build info (public)
+	r.GET("/healthz", healthController.Healthz)
+	r.GET("/readyz", healthController.Readyz)
+	r.GET("/version", healthController.Version)
+
 	// Group: /api
 	api := r.Group("/api")
 	{
 		// Auth routes (public)
 		auth := api.Group("/auth")
 		{
-			auth.POST("/login", controller.Login)
-			auth.POST("/logout", controller.Login)
-			auth.GET("/me", middleware.AuthMiddleware(), controller.GetMe)
+			auth.POST("/login", authController.Login)
+			auth.POST("/logout", authController.Login)
+			auth.GET("/me", authMiddleware, authController.GetMe)
 		}
 
 		// Protected routes (cookie-based JWT)
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(authMiddleware)
 
 		// Employee routes
 		employee := protected.Group("/employee")
 		{
-			employee.POST("/GetData", controller.GetAllEmployees)
-			employee.GET("/:employee_id", controller.GetEmployeeByID)
-			employee.POST("", controller.CreateEmployee)
-			employee.PUT("/:id", controller.UpdateEmployee)
-			employee.DELETE("/:id", controller.DeleteEmployee)
+			employee.POST("/GetData", employeeController.GetAllEmployees)
+			employee.GET("/:id", employeeController.GetEmployeeByID)
+			employee.GET("/:id/events", employeeController.GetEmployeeEvents)
+			employee.POST("/:id/events", employeeController.CreateEmployeeEvent)
+			employee.POST("", employeeController.CreateEmployee)
+			employee.POST("/import", employeeController.ImportEmployees)
+			employee.GET("/trash", employeeController.GetEmployeeTrash)
+			employee.POST("/:id/restore", employeeController.RestoreEmployee)
+			employee.PUT("/:id", employeeController.UpdateEmployee)
+			employee.DELETE("/:id", employeeController.DeleteEmployee)
 		}
 
 		// Departement routes
 		departement := protected.Group("/departement")
 		{
-			departement.POST("/GetData", controller.GetAllDepartements)
-			departement.GET("/:id", controller.GetDepartementByID)
-			departement.POST("", controller.CreateDepartement)
-			departement.PUT("/:id", controller.UpdateDepartement)
-			departement.DELETE("/:id", controller.DeleteDepartement)
+			departement.POST("/GetData", departementController.GetAllDepartements)
+			departement.GET("/tree", departementController.GetDepartementTree)
+			departement.GET("/trash", departementController.GetDepartementTrash)
+			departement.POST("/:id/restore", departementController.RestoreDepartement)
+			departement.GET("/:id", departementController.GetDepartementByID)
+			departement.POST("", departementController.CreateDepartement)
+			departement.PUT("/:id", departementController.UpdateDepartement)
+			departement.DELETE("/:id", departementController.DeleteDepartement)
 		}
 
 		//  Attendance routes
 		attendance := protected.Group("/attendance")
 		{
-			attendance.POST("", controller.ClockHandler)
-			attendance.GET("/today", controller.GetTodayAttendance)
-			attendance.POST("/logs", controller.GetAttendanceLogs)
-			attendance.POST("/GetData", controller.GetAllAttendanceLogs)
+			attendance.POST("", attendanceController.ClockHandler)
+			attendance.GET("/today", attendanceController.GetTodayAttendance)
+			attendance.POST("/logs", attendanceController.GetAttendanceLogs)
+			attendance.POST("/GetData", attendanceController.GetAllAttendanceLogs)
+			attendance.POST("/summary", attendanceController.GetAttendanceSummary)
+		}
+
+		// Employee search
+		protected.GET("/search", searchController.Search)
+
+		// Admin routes
+		admin := protected.Group("/admin")
+		admin.Use(middleware.RequireAdmin())
+		{
+			admin.POST("/purge", adminController.PurgeDeleted)
+		}
+
+		// Audit trail (admin only)
+		audit := protected.Group("/audit")
+		audit.Use(middleware.RequireAdmin())
+		{
+			audit.GET("", adminController.GetAuditLogs)
+		}
+	}
+
+	// Group: /api/v2, resource-oriented routes addressed by row ID. /api
+	// above stays as it is for the existing frontend.
+	v2 := r.Group("/api/v2")
+	{
+		auth := v2.Group("/auth")
+		{
+			auth.POST("/login", authController.Login)
+			auth.POST("/logout", authController.Logout)
+		}
+
+		protected := v2.Group("/")
+		protected.Use(authMiddleware)
+
+		// The logged-in employee
+		me := protected.Group("/me")
+		{
+			me.GET("", authController.GetMe)
+			me.GET("/attendance", attendanceController.ListMyAttendance)
+			me.POST("/attendance", attendanceController.ClockHandler)
+			me.GET("/attendance/today", attendanceController.GetTodayAttendance)
+		}
+
+		employees := protected.Group("/employees")
+		{
+			employees.GET("", employeeController.ListEmployees)
+			employees.POST("", employeeController.CreateEmployeeV2)
+			employees.POST("/import", employeeController.ImportEmployees)
+			employees.GET("/trash", employeeController.GetEmployeeTrash)
+			employees.GET("/:id", employeeController.GetEmployeeByID)
+			employees.PATCH("/:id", employeeController.UpdateEmployee)
+			employees.DELETE("/:id", employeeController.DeleteEmployeeV2)
+			employees.POST("/:id/restore", employeeController.RestoreEmployee)
+			employees.GET("/:id/events", employeeController.GetEmployeeEvents)
+			employees.POST("/:id/events", employeeController.CreateEmployeeEvent)
+			employees.GET("/:id/attendance", attendanceController.ListEmployeeAttendance)
+		}
+
+		departements := protected.Group("/departements")
+		{
+			departements.GET("", departementController.ListDepartements)
+			departements.POST("", departementController.CreateDepartementV2)
+			departements.GET("/tree", departementController.GetDepartementTree)
+			departements.GET("/trash", departementController.GetDepartementTrash)
+			departements.GET("/:id", departementController.GetDepartementByID)
+			departements.PATCH("/:id", departementController.UpdateDepartement)
+			departements.DELETE("/:id", departementController.DeleteDepartementV2)
+			departements.POST("/:id/restore", departementController.RestoreDepartement)
+		}
+
+		attendance := protected.Group("/attendance")
+		{
+			attendance.GET("", attendanceController.ListAttendance)
+			attendance.GET("/summary", attendanceController.GetAttendanceSummaryV2)
+		}
+
+		protected.GET("/search", searchController.Search)
+
+		admin := protected.Group("/admin")
+		admin.Use(middleware.RequireAdmin())
+		{
+			admin.POST("/purge", adminController.PurgeDeleted)
+		}
 
+		audit := protected.Group("/audit")
+		audit.Use(middleware.RequireAdmin())
+		{
+			audit.GET("", adminController.GetAuditLogs)
 		}
 	}
+
+	return nil
 }
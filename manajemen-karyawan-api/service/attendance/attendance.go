@@ -0,0 +1,601 @@
+// Package attendance holds the clocking rules and the on-time/late/early
+// status derivation shared by the HTTP handlers, the CLI and background jobs.
+package attendance
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/metrics"
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/utils"
+)
+
+// DefaultTimezone is the business timezone used when none is configured.
+const DefaultTimezone = "Asia/Singapore"
+
+// Values of attendance_history.attendance_type
+const (
+	TypeClockIn  = 1
+	TypeClockOut = 2
+)
+
+// Clock request types accepted by Clock
+const (
+	ClockIn  = "clock_in"
+	ClockOut = "clock_out"
+)
+
+const (
+	StatusOnTime     = "Tepat"
+	StatusLate       = "Terlambat"
+	StatusEarlyLeave = "Pulang Cepat"
+	StatusUnknown    = "Unknown"
+)
+
+// AutoCloseDescription is the history description of clock-outs written by
+// CloseOpen.
+const AutoCloseDescription = "Ditutup otomatis"
+
+var (
+	ErrInvalidType         = apperr.Invalid("type", "oneof", "type must be clock_in or clock_out")
+	ErrAlreadyClockedIn    = apperr.New(apperr.CodeAlreadyClockedIn, "already clocked in today")
+	ErrNotClockedIn        = apperr.New(apperr.CodeNotClockedIn, "no clock-in record found")
+	ErrDepartementNotFound = apperr.NotFound("departement not found")
+	ErrEmployeeNotFound    = apperr.NotFound("employee not found")
+	ErrInvalidDate         = apperr.BadRequest("date must be YYYY-MM-DD")
+)
+
+type Service struct {
+	store repository.Store
+	loc   *time.Location
+	// Loaded departement timezones by name
+	zones sync.Map
+}
+
+// NewService returns a Service evaluating days and clock rules in the company
+// timezone loc, unless a departement sets its own. A nil loc falls back to
+// DefaultTimezone.
+func NewService(store repository.Store, loc *time.Location) *Service {
+	if loc == nil {
+		var err error
+		if loc, err = time.LoadLocation(DefaultTimezone); err != nil {
+			loc = time.Local
+		}
+	}
+	return &Service{store: store, loc: loc}
+}
+
+// Location returns the company timezone.
+func (s *Service) Location() *time.Location {
+	return s.loc
+}
+
+// locationOf returns the timezone of a departement, inherited from its
+// ancestors, or the company timezone when none is set or it fails to load.
+func (s *Service) locationOf(tree model.DepartementTree, departementID string) *time.Location {
+	name := tree.Timezone(departementID)
+	if name == "" {
+		return s.loc
+	}
+	if loc, ok := s.zones.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return s.loc
+	}
+	s.zones.Store(name, loc)
+	return loc
+}
+
+// employeeDepartement loads the departement tree and the current departement
+// of the employee with code employeeID, which is empty for unknown employees.
+func (s *Service) employeeDepartement(ctx context.Context, employeeID string) (model.DepartementTree, string, error) {
+	tree, err := s.store.Departements().Tree(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	emp, err := s.store.Employees().GetByEmployeeID(ctx, employeeID)
+	if err == repository.ErrNotFound {
+		return tree, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	return tree, emp.DepartementID, nil
+}
+
+// employeeLocation returns the timezone of the current departement of the
+// employee with code employeeID.
+func (s *Service) employeeLocation(ctx context.Context, employeeID string) (*time.Location, error) {
+	tree, departementID, err := s.employeeDepartement(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.locationOf(tree, departementID), nil
+}
+
+// DayRange returns the start and end of the company business day containing t.
+func (s *Service) DayRange(t time.Time) (time.Time, time.Time) {
+	return dayRange(t, s.loc)
+}
+
+// dayRange returns the start and end of the calendar day in loc containing t.
+// Days are not always 24 hours long around DST changes.
+func dayRange(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return start, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+}
+
+// businessDay formats the calendar day of t in loc, so that days of
+// departements in different timezones can be compared.
+func businessDay(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02")
+}
+
+// ParseDate parses a YYYY-MM-DD date as the start of that company business day.
+func (s *Service) ParseDate(raw string) (time.Time, error) {
+	d, err := time.ParseInLocation("2006-01-02", raw, s.loc)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return d, nil
+}
+
+// clockTime keeps only the time of day of t in loc.
+func clockTime(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(0, 1, 1, t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
+
+// IsLate reports whether clockIn, read as wall-clock time in loc, is after
+// the HH:MM:SS limit maxRaw.
+func IsLate(clockIn time.Time, maxRaw string, loc *time.Location) (bool, error) {
+	max, err := time.ParseInLocation("15:04:05", maxRaw, loc)
+	if err != nil {
+		return false, err
+	}
+	return clockTime(clockIn, loc).After(max), nil
+}
+
+// IsEarly reports whether clockOut, read as wall-clock time in loc, is
+// before the HH:MM:SS limit maxRaw.
+func IsEarly(clockOut time.Time, maxRaw string, loc *time.Location) (bool, error) {
+	max, err := time.ParseInLocation("15:04:05", maxRaw, loc)
+	if err != nil {
+		return false, err
+	}
+	return clockTime(clockOut, loc).Before(max), nil
+}
+
+// Status derives the status label of a clock-in or clock-out at t against
+// the departement limit maxRaw, in the departement timezone loc.
+func Status(attendanceType int, t time.Time, maxRaw string, loc *time.Location) string {
+	switch attendanceType {
+	case TypeClockIn:
+		late, err := IsLate(t, maxRaw, loc)
+		if err != nil {
+			return StatusUnknown
+		} else if late {
+			return StatusLate
+		}
+		return StatusOnTime
+	case TypeClockOut:
+		early, err := IsEarly(t, maxRaw, loc)
+		if err != nil {
+			return StatusUnknown
+		} else if early {
+			return StatusEarlyLeave
+		}
+		return StatusOnTime
+	}
+	return StatusUnknown
+}
+
+// Clock records a clock-in or clock-out of employeeID at now. An employee can
+// clock in once per business day of their departement and clock out only
+// after clocking in.
+func (s *Service) Clock(ctx context.Context, employeeID string, clockType string, description string, now time.Time) error {
+	tree, departementID, err := s.employeeDepartement(ctx, employeeID)
+	if err != nil {
+		return err
+	}
+	loc := s.locationOf(tree, departementID)
+	start, end := dayRange(now, loc)
+
+	history := model.AttendanceHistory{
+		EmployeeID:     employeeID,
+		DateAttendance: now,
+		Description:    description,
+	}
+	history.CreatedAt = now
+	history.CreatedBy = employeeID
+
+	switch clockType {
+	case ClockIn:
+		a := model.Attendance{
+			ID:         utils.GenerateID(),
+			EmployeeID: employeeID,
+			ClockIn:    &now,
+		}
+		a.CreatedAt = now
+		a.CreatedBy = employeeID
+
+		history.AttendanceID = a.ID
+		history.AttendanceType = TypeClockIn
+
+		// The check runs under the employee lock, so two concurrent
+		// clock-ins cannot both find the day empty.
+		err = s.store.WithTx(ctx, func(tx repository.Store) error {
+			if err := tx.Attendance().LockEmployee(ctx, employeeID); err != nil {
+				return err
+			}
+			_, err := tx.Attendance().FindByDay(ctx, employeeID, start, end)
+			if err == nil {
+				return ErrAlreadyClockedIn
+			} else if err != repository.ErrNotFound {
+				return err
+			}
+
+			if err := tx.Attendance().Create(ctx, a); err != nil {
+				return err
+			}
+			return tx.Attendance().AddHistory(ctx, history)
+		})
+		if err != nil {
+			return err
+		}
+
+		metrics.ClockEvents.WithLabelValues(ClockIn).Inc()
+		if maxIn, _ := tree.ClockRules(departementID); Status(TypeClockIn, now, maxIn, loc) == StatusLate {
+			metrics.LateClockIns.Inc()
+		}
+		return nil
+
+	case ClockOut:
+		history.AttendanceType = TypeClockOut
+
+		err = s.store.WithTx(ctx, func(tx repository.Store) error {
+			if err := tx.Attendance().LockEmployee(ctx, employeeID); err != nil {
+				return err
+			}
+			a, err := tx.Attendance().FindByDay(ctx, employeeID, start, end)
+			if err == repository.ErrNotFound {
+				return ErrNotClockedIn
+			} else if err != nil {
+				return err
+			}
+
+			history.AttendanceID = a.ID
+			if err := tx.Attendance().SetClockOut(ctx, a.ID, now, employeeID); err != nil {
+				return err
+			}
+			return tx.Attendance().AddHistory(ctx, history)
+		})
+		if err != nil {
+			return err
+		}
+
+		metrics.ClockEvents.WithLabelValues(ClockOut).Inc()
+		return nil
+	}
+
+	return ErrInvalidType
+}
+
+// Today returns the attendance of employeeID for the current business day of
+// their departement, with times in its timezone.
+func (s *Service) Today(ctx context.Context, employeeID string) (model.Attendance, error) {
+	loc, err := s.employeeLocation(ctx, employeeID)
+	if err != nil {
+		return model.Attendance{}, err
+	}
+
+	start, end := dayRange(time.Now(), loc)
+	a, err := s.store.Attendance().FindByDay(ctx, employeeID, start, end)
+	if err != nil {
+		return a, err
+	}
+	a.ClockIn = inLocation(a.ClockIn, loc)
+	a.ClockOut = inLocation(a.ClockOut, loc)
+	return a, nil
+}
+
+func inLocation(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.In(loc)
+	return &v
+}
+
+// EmployeeLogs is Logs of the employee with row ID id rather than employee
+// code.
+func (s *Service) EmployeeLogs(ctx context.Context, params utils.QueryParams, id string) ([]model.AttendanceItem, utils.PageInfo, error) {
+	e, err := s.store.Employees().GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, utils.PageInfo{}, ErrEmployeeNotFound
+	} else if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+	return s.Logs(ctx, params, e.EmployeeID)
+}
+
+// Logs lists attendance entries with their status, limited to employeeID
+// unless it is empty. Times are in the timezone of the departement.
+func (s *Service) Logs(ctx context.Context, params utils.QueryParams, employeeID string) ([]model.AttendanceItem, utils.PageInfo, error) {
+	// Filter dates are company days
+	params.Location = s.loc
+	rows, page, err := s.store.Attendance().ListLogs(ctx, params, employeeID)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+
+	tree, err := s.store.Departements().Tree(ctx)
+	if err != nil {
+		return nil, utils.PageInfo{}, err
+	}
+
+	var logs []model.AttendanceItem
+	for _, row := range rows {
+		maxIn, maxOut := tree.ClockRules(row.DepartementID)
+		loc := s.locationOf(tree, row.DepartementID)
+
+		item := model.AttendanceItem{
+			ID:              row.ID,
+			EmployeeID:      row.EmployeeID,
+			EmployeeName:    row.EmployeeName,
+			DepartementName: row.DepartementName,
+			DateAttendance:  row.DateAttendance.In(loc),
+			Desc:            row.Description,
+		}
+
+		if row.AttendanceType == TypeClockIn {
+			item.Clock = row.ClockIn.In(loc)
+			item.MaxClock = maxIn
+			item.AttendanceType = "in"
+			item.Status = Status(TypeClockIn, row.ClockIn, maxIn, loc)
+		} else if row.AttendanceType == TypeClockOut && row.ClockOut != nil {
+			item.Clock = row.ClockOut.In(loc)
+			item.MaxClock = maxOut
+			item.AttendanceType = "out"
+			item.Status = Status(TypeClockOut, *row.ClockOut, maxOut, loc)
+		}
+
+		logs = append(logs, item)
+	}
+	return logs, page, nil
+}
+
+// Summary counts clock-ins, late arrivals, clock-outs and early leaves per
+// departement for the business days from..to (inclusive), each entry counted
+// on its day in the departement timezone. Each node includes the counts of
+// its sub-departements. A non-empty departementID limits the result to that
+// subtree.
+func (s *Service) Summary(ctx context.Context, from time.Time, to time.Time, departementID string) ([]model.DepartementAttendanceSummary, error) {
+	tree, err := s.store.Departements().Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if departementID != "" {
+		if _, ok := tree[departementID]; !ok {
+			return nil, ErrDepartementNotFound
+		}
+	}
+
+	// Departement days may start up to a day apart from company days
+	rows, err := s.store.Attendance().LogsBetween(ctx, from.AddDate(0, 0, -1), to.AddDate(0, 0, 2))
+	if err != nil {
+		return nil, err
+	}
+	firstDay, lastDay := businessDay(from, s.loc), businessDay(to, s.loc)
+
+	own := map[string]*model.DepartementAttendanceSummary{}
+	for id, node := range tree {
+		own[id] = &model.DepartementAttendanceSummary{
+			DepartementID:   id,
+			DepartementName: node.DepartementName,
+		}
+	}
+
+	for _, row := range rows {
+		sum, ok := own[row.DepartementID]
+		if !ok {
+			continue
+		}
+
+		loc := s.locationOf(tree, row.DepartementID)
+		if day := businessDay(row.DateAttendance, loc); day < firstDay || day > lastDay {
+			continue
+		}
+
+		maxIn, maxOut := tree.ClockRules(row.DepartementID)
+		if row.AttendanceType == TypeClockIn {
+			sum.ClockIn++
+			if Status(TypeClockIn, row.ClockIn, maxIn, loc) == StatusLate {
+				sum.Late++
+			}
+		} else if row.AttendanceType == TypeClockOut && row.ClockOut != nil {
+			sum.ClockOut++
+			if Status(TypeClockOut, *row.ClockOut, maxOut, loc) == StatusEarlyLeave {
+				sum.EarlyLeave++
+			}
+		}
+	}
+
+	children := tree.Children()
+	var rollUp func(id string) model.DepartementAttendanceSummary
+	rollUp = func(id string) model.DepartementAttendanceSummary {
+		sum := *own[id]
+		sum.Children = []model.DepartementAttendanceSummary{}
+		for _, childID := range children[id] {
+			child := rollUp(childID)
+			sum.ClockIn += child.ClockIn
+			sum.Late += child.Late
+			sum.ClockOut += child.ClockOut
+			sum.EarlyLeave += child.EarlyLeave
+			sum.Children = append(sum.Children, child)
+		}
+		return sum
+	}
+
+	roots := children[""]
+	if departementID != "" {
+		roots = []string{departementID}
+	}
+
+	result := []model.DepartementAttendanceSummary{}
+	for _, id := range roots {
+		result = append(result, rollUp(id))
+	}
+	return result, nil
+}
+
+// ClosedAttendance is an attendance clocked out by CloseOpen.
+type ClosedAttendance struct {
+	AttendanceID string
+	EmployeeID   string
+	ClockIn      time.Time
+	ClockOut     time.Time
+}
+
+// CloseOpen clocks out every attendance clocked in before the given time and
+// never clocked out. The clock-out is set to the departement limit on the
+// clock-in day, or to the clock-in itself when no later limit applies. With
+// dryRun nothing is written.
+func (s *Service) CloseOpen(ctx context.Context, before time.Time, actor string, dryRun bool) ([]ClosedAttendance, error) {
+	rows, err := s.store.Attendance().OpenBefore(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := s.store.Departements().Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	closed := make([]ClosedAttendance, 0, len(rows))
+	for _, row := range rows {
+		_, maxOut := tree.ClockRules(row.DepartementID)
+		closed = append(closed, ClosedAttendance{
+			AttendanceID: row.ID,
+			EmployeeID:   row.EmployeeID,
+			ClockIn:      row.ClockIn,
+			ClockOut:     closingTime(row.ClockIn, maxOut, s.locationOf(tree, row.DepartementID)),
+		})
+	}
+	if dryRun || len(closed) == 0 {
+		return closed, nil
+	}
+
+	now := time.Now()
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		for _, c := range closed {
+			if err := tx.Attendance().SetClockOut(ctx, c.AttendanceID, c.ClockOut, actor); err != nil {
+				return err
+			}
+
+			history := model.AttendanceHistory{
+				EmployeeID:     c.EmployeeID,
+				AttendanceID:   c.AttendanceID,
+				DateAttendance: c.ClockOut,
+				AttendanceType: TypeClockOut,
+				Description:    AutoCloseDescription,
+			}
+			history.CreatedAt = now
+			history.CreatedBy = actor
+			if err := tx.Attendance().AddHistory(ctx, history); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return closed, nil
+}
+
+// closingTime puts the HH:MM:SS limit maxRaw on the day of clockIn in loc.
+func closingTime(clockIn time.Time, maxRaw string, loc *time.Location) time.Time {
+	max, err := time.ParseInLocation("15:04:05", maxRaw, loc)
+	if err != nil {
+		return clockIn
+	}
+	day := clockIn.In(loc)
+	out := time.Date(day.Year(), day.Month(), day.Day(), max.Hour(), max.Minute(), max.Second(), 0, loc)
+	if out.Before(clockIn) {
+		return clockIn
+	}
+	return out
+}
+
+// MonthlyReport aggregates the attendance of every employee in the business
+// month containing month, ordered by employee code. Entries belong to the
+// month of their day in the departement timezone. The departement is the one
+// the employee belonged to on their last attendance of the month.
+func (s *Service) MonthlyReport(ctx context.Context, month time.Time) ([]model.EmployeeMonthlyReport, error) {
+	month = month.In(s.loc)
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, s.loc)
+	monthKey := from.Format("2006-01")
+
+	rows, err := s.store.Attendance().LogsBetween(ctx, from.AddDate(0, 0, -1), from.AddDate(0, 1, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := s.store.Departements().Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := map[string]*model.EmployeeMonthlyReport{}
+	lastSeen := map[string]time.Time{}
+	for _, row := range rows {
+		loc := s.locationOf(tree, row.DepartementID)
+		if row.DateAttendance.In(loc).Format("2006-01") != monthKey {
+			continue
+		}
+
+		r, ok := reports[row.EmployeeID]
+		if !ok {
+			r = &model.EmployeeMonthlyReport{EmployeeID: row.EmployeeID, EmployeeName: row.EmployeeName}
+			reports[row.EmployeeID] = r
+		}
+		if !row.DateAttendance.Before(lastSeen[row.EmployeeID]) {
+			lastSeen[row.EmployeeID] = row.DateAttendance
+			r.DepartementName = row.DepartementName
+		}
+
+		maxIn, maxOut := tree.ClockRules(row.DepartementID)
+		if row.AttendanceType == TypeClockIn {
+			r.DaysPresent++
+			if Status(TypeClockIn, row.ClockIn, maxIn, loc) == StatusLate {
+				r.Late++
+			}
+			if row.ClockOut != nil {
+				r.HoursWorked += row.ClockOut.Sub(row.ClockIn).Hours()
+			}
+		} else if row.AttendanceType == TypeClockOut && row.ClockOut != nil {
+			r.ClockOut++
+			if Status(TypeClockOut, *row.ClockOut, maxOut, loc) == StatusEarlyLeave {
+				r.EarlyLeave++
+			}
+		}
+	}
+
+	result := make([]model.EmployeeMonthlyReport, 0, len(reports))
+	for _, r := range reports {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].EmployeeID < result[j].EmployeeID })
+	return result, nil
+}
@@ -0,0 +1,374 @@
+package attendance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+	"manajemen-karyawan-api/repository/memstore"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// newTestService returns a Service over newTestStore.
+func newTestService(t *testing.T, company *time.Location, zones map[string]string) *Service {
+	return NewService(newTestStore(t, company, zones), company)
+}
+
+// newTestStore returns a store holding one departement per entry of zones,
+// keyed by ID with its timezone ("" for the company one), and one employee
+// "E-<id>" in each. Every departement limits clock-in to 08:00 and clock-out
+// to 17:00.
+func newTestStore(t *testing.T, company *time.Location, zones map[string]string) *memstore.Store {
+	t.Helper()
+	ctx := context.Background()
+	store := memstore.New(company)
+	for id, zone := range zones {
+		node := model.DepartementNode{
+			ID:              id,
+			DepartementName: id,
+			MaxClockInTime:  strPtr("08:00:00"),
+			MaxClockOutTime: strPtr("17:00:00"),
+		}
+		if zone != "" {
+			node.Timezone = strPtr(zone)
+		}
+		if err := store.Departements().Create(ctx, node, "test", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		e := model.Employee{ID: "row-" + id, EmployeeID: "E-" + id, DepartementID: id, Name: "Employee " + id}
+		if err := store.Employees().Create(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestDayRangeAroundMidnight(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		day   string
+		start time.Time
+	}{
+		{"one second before local midnight", utc("2024-03-10T16:59:59Z"), "2024-03-10", utc("2024-03-09T17:00:00Z")},
+		{"at local midnight", utc("2024-03-10T17:00:00Z"), "2024-03-11", utc("2024-03-10T17:00:00Z")},
+		{"just after local midnight, still the previous UTC day", utc("2024-03-10T17:00:01Z"), "2024-03-11", utc("2024-03-10T17:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := dayRange(tt.at, jakarta)
+			if !start.Equal(tt.start) || end.Sub(start) != 24*time.Hour {
+				t.Errorf("dayRange = %v, %v; want start %v", start, end, tt.start)
+			}
+			if day := businessDay(tt.at, jakarta); day != tt.day {
+				t.Errorf("businessDay = %s, want %s", day, tt.day)
+			}
+		})
+	}
+}
+
+// clockStep is a Clock request of the given type at an RFC 3339 time.
+type clockStep struct {
+	clockType string
+	at        string
+}
+
+func TestClockAroundMidnight(t *testing.T) {
+	jakarta := mustLoad(t, "Asia/Jakarta")
+
+	tests := []struct {
+		name string
+		// Clock requests in order; the last one is checked against want
+		steps []clockStep
+		want  error
+	}{
+		{
+			name: "clock-in on both sides of midnight",
+			steps: []clockStep{
+				{ClockIn, "2024-03-10T16:59:00Z"},
+				{ClockIn, "2024-03-10T17:01:00Z"},
+			},
+		},
+		{
+			name: "second clock-in before midnight",
+			steps: []clockStep{
+				{ClockIn, "2024-03-10T01:00:00Z"},
+				{ClockIn, "2024-03-10T16:59:59Z"},
+			},
+			want: ErrAlreadyClockedIn,
+		},
+		{
+			name: "clock-out after midnight belongs to the next day",
+			steps: []clockStep{
+				{ClockIn, "2024-03-10T16:30:00Z"},
+				{ClockOut, "2024-03-10T17:30:00Z"},
+			},
+			want: ErrNotClockedIn,
+		},
+		{
+			name: "clock-out just before midnight",
+			steps: []clockStep{
+				{ClockIn, "2024-03-10T01:00:00Z"},
+				{ClockOut, "2024-03-10T16:59:59Z"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, jakarta, map[string]string{"ops": ""})
+			var err error
+			for i, step := range tt.steps {
+				if err != nil {
+					t.Fatalf("step %d: %v", i-1, err)
+				}
+				err = svc.Clock(context.Background(), "E-ops", step.clockType, "test", utc(step.at))
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("last Clock = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// slowStore widens the window between looking up the day's attendance and
+// acting on it, so unsynchronised clock requests would overlap.
+type slowStore struct{ repository.Store }
+
+func (s slowStore) Attendance() repository.AttendanceRepository {
+	return slowAttendance{s.Store.Attendance()}
+}
+
+func (s slowStore) WithTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	return s.Store.WithTx(ctx, func(tx repository.Store) error { return fn(slowStore{tx}) })
+}
+
+type slowAttendance struct {
+	repository.AttendanceRepository
+}
+
+func (a slowAttendance) FindByDay(ctx context.Context, employeeID string, from time.Time, to time.Time) (model.Attendance, error) {
+	found, err := a.AttendanceRepository.FindByDay(ctx, employeeID, from, to)
+	time.Sleep(time.Millisecond)
+	return found, err
+}
+
+func TestConcurrentClockIn(t *testing.T) {
+	svc := NewService(slowStore{newTestStore(t, time.UTC, map[string]string{"ops": ""})}, time.UTC)
+	at := utc("2024-03-11T07:00:00Z")
+
+	const n = 20
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = svc.Clock(context.Background(), "E-ops", ClockIn, "test", at)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrAlreadyClockedIn):
+			t.Errorf("Clock = %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d of %d concurrent clock-ins succeeded, want 1", succeeded, n)
+	}
+}
+
+func TestDayRangeAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		length time.Duration
+	}{
+		{"spring forward", utc("2024-03-10T12:00:00Z"), 23 * time.Hour},
+		{"fall back", utc("2024-11-03T12:00:00Z"), 25 * time.Hour},
+		{"ordinary day", utc("2024-03-11T12:00:00Z"), 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := dayRange(tt.at, newYork)
+			if got := end.Sub(start); got != tt.length {
+				t.Errorf("day is %v long, want %v", got, tt.length)
+			}
+			if s := start.In(newYork); s.Hour() != 0 || s.Minute() != 0 {
+				t.Errorf("day starts at %v", s)
+			}
+		})
+	}
+}
+
+func TestStatusAcrossDST(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+
+	// 13:30 UTC is 08:30 EST before springing forward and 09:30 EDT after;
+	// 21:30 UTC is 17:30 EDT before falling back and 16:30 EST after
+	tests := []struct {
+		name           string
+		attendanceType int
+		at             time.Time
+		max            string
+		want           string
+	}{
+		{"clock-in before springing forward", TypeClockIn, utc("2024-03-08T13:30:00Z"), "09:00:00", StatusOnTime},
+		{"same UTC clock-in after springing forward", TypeClockIn, utc("2024-03-11T13:30:00Z"), "09:00:00", StatusLate},
+		{"clock-in on the short day", TypeClockIn, utc("2024-03-10T13:30:00Z"), "09:00:00", StatusLate},
+		{"clock-out before falling back", TypeClockOut, utc("2024-11-01T21:30:00Z"), "17:00:00", StatusOnTime},
+		{"same UTC clock-out after falling back", TypeClockOut, utc("2024-11-04T21:30:00Z"), "17:00:00", StatusEarlyLeave},
+		{"clock-out on the long day", TypeClockOut, utc("2024-11-03T22:00:00Z"), "17:00:00", StatusOnTime},
+		{"unparsable limit", TypeClockIn, utc("2024-03-11T13:30:00Z"), "9am", StatusUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Status(tt.attendanceType, tt.at, tt.max, newYork); got != tt.want {
+				t.Errorf("Status = %q, want %q (local %v)", got, tt.want, tt.at.In(newYork))
+			}
+		})
+	}
+}
+
+// summaryOf finds the counts of departement id in a Summary result.
+func summaryOf(t *testing.T, result []model.DepartementAttendanceSummary, id string) model.DepartementAttendanceSummary {
+	t.Helper()
+	for _, s := range result {
+		if s.DepartementID == id {
+			return s
+		}
+	}
+	t.Fatalf("no summary for %s in %+v", id, result)
+	return model.DepartementAttendanceSummary{}
+}
+
+func TestDepartementTimezone(t *testing.T) {
+	singapore := mustLoad(t, "Asia/Singapore")
+	ctx := context.Background()
+
+	// "hq" uses the company timezone (UTC+8), "branch" is an hour behind
+	svc := newTestService(t, singapore, map[string]string{"hq": "", "branch": "Asia/Jakarta"})
+
+	// 00:30 UTC is 08:30 at hq (late) but 07:30 at the branch (on time)
+	for _, code := range []string{"E-hq", "E-branch"} {
+		if err := svc.Clock(ctx, code, ClockIn, "test", utc("2024-03-05T00:30:00Z")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 16:30 UTC on the 5th is still the 5th at the branch, so the branch
+	// employee has already clocked in; at hq it is the 6th
+	if err := svc.Clock(ctx, "E-branch", ClockIn, "test", utc("2024-03-05T16:30:00Z")); !errors.Is(err, ErrAlreadyClockedIn) {
+		t.Errorf("branch clock-in at 23:30 local = %v, want ErrAlreadyClockedIn", err)
+	}
+	if err := svc.Clock(ctx, "E-hq", ClockIn, "test", utc("2024-03-05T16:30:00Z")); err != nil {
+		t.Errorf("hq clock-in at 00:30 local = %v", err)
+	}
+
+	day := func(s string) time.Time {
+		d, err := svc.ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		id       string
+		clockIn  int
+		late     int
+	}{
+		{"hq on the 5th", "2024-03-05", "2024-03-05", "hq", 1, 1},
+		{"hq on the 6th", "2024-03-06", "2024-03-06", "hq", 1, 0},
+		{"branch on the 5th", "2024-03-05", "2024-03-05", "branch", 1, 0},
+		{"branch on the 6th", "2024-03-06", "2024-03-06", "branch", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.Summary(ctx, day(tt.from), day(tt.to), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := summaryOf(t, result, tt.id)
+			if got.ClockIn != tt.clockIn || got.Late != tt.late {
+				t.Errorf("clockIn, late = %d, %d; want %d, %d", got.ClockIn, got.Late, tt.clockIn, tt.late)
+			}
+		})
+	}
+}
+
+func TestSummaryInclusiveDateTo(t *testing.T) {
+	singapore := mustLoad(t, "Asia/Singapore")
+	ctx := context.Background()
+	svc := newTestService(t, singapore, map[string]string{"hq": ""})
+
+	// Clock-ins at 07:00 on the 1st, 23:59 on the 5th and 00:00 on the 6th
+	for _, at := range []string{"2024-02-29T23:00:00Z", "2024-03-05T15:59:00Z", "2024-03-05T16:00:00Z"} {
+		if err := svc.Clock(ctx, "E-hq", ClockIn, "test", utc(at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		from, to string
+		clockIn  int
+		late     int
+	}{
+		{"2024-03-01", "2024-03-05", 2, 1},
+		{"2024-03-05", "2024-03-05", 1, 1},
+		{"2024-03-01", "2024-03-06", 3, 1},
+		{"2024-03-02", "2024-03-04", 0, 0},
+		{"2024-03-06", "2024-03-01", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"..."+tt.to, func(t *testing.T) {
+			from, _ := svc.ParseDate(tt.from)
+			to, _ := svc.ParseDate(tt.to)
+			result, err := svc.Summary(ctx, from, to, "hq")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result) != 1 || result[0].ClockIn != tt.clockIn || result[0].Late != tt.late {
+				t.Errorf("Summary = %+v, want %d clock-ins, %d late", result, tt.clockIn, tt.late)
+			}
+		})
+	}
+}
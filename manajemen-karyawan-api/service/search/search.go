@@ -0,0 +1,366 @@
+// Package search keeps an in-memory inverted index of the active employees
+// for ranked, typo-tolerant lookups across their code, name, address,
+// departement and position.
+//
+// The index is loaded once at startup. Handlers that change employees
+// refresh the rows they touched, and a periodic Rebuild picks up changes
+// made by the CLI or by other instances of the API.
+package search
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"manajemen-karyawan-api/model"
+	"manajemen-karyawan-api/repository"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+
+	// Rows loaded per query when refreshing many employees at once
+	refreshBatch = 500
+	// Further query words are ignored
+	maxQueryTerms = 8
+)
+
+// Hit is one employee matching a query.
+type Hit struct {
+	ID              string  `json:"id"`
+	EmployeeID      string  `json:"employeeID"`
+	Name            string  `json:"name"`
+	DepartementID   string  `json:"departementID"`
+	DepartementName string  `json:"departementName"`
+	Address         string  `json:"address"`
+	Position        *string `json:"position,omitempty"`
+	Status          string  `json:"status"`
+	Score           float64 `json:"score"`
+	// Matched names the fields the query was found in.
+	Matched []string `json:"matched"`
+}
+
+// field is one indexed employee attribute; weight ranks a match in an
+// employee code above one in a name, above one in the address.
+type field struct {
+	name   string
+	weight float64
+	value  func(e model.Employee) string
+}
+
+var fields = []field{
+	{"employeeID", 4, func(e model.Employee) string { return e.EmployeeID }},
+	{"name", 3, func(e model.Employee) string { return e.Name }},
+	{"position", 2, func(e model.Employee) string {
+		if e.Position == nil {
+			return ""
+		}
+		return *e.Position
+	}},
+	{"departementName", 1.5, func(e model.Employee) string { return e.DepartementName }},
+	{"address", 1, func(e model.Employee) string { return e.Address }},
+}
+
+// fieldSet is a bit per entry of fields.
+type fieldSet uint8
+
+func (s fieldSet) names() []string {
+	var names []string
+	for i, f := range fields {
+		if s&(1<<i) != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// posting is the occurrence of a term in one employee.
+type posting struct {
+	weight float64
+	fields fieldSet
+}
+
+type document struct {
+	hit     Hit
+	version int
+	terms   []string
+}
+
+type Index struct {
+	store repository.Store
+	// Serializes Rebuild
+	rebuilding sync.Mutex
+
+	mu sync.RWMutex
+	// By employee row ID
+	docs map[string]*document
+	// Term to employee row ID
+	postings map[string]map[string]posting
+	// IDs refreshed while a Rebuild was loading; nil when none runs
+	touched map[string]bool
+}
+
+func NewIndex(store repository.Store) *Index {
+	return &Index{
+		store:    store,
+		docs:     map[string]*document{},
+		postings: map[string]map[string]posting{},
+	}
+}
+
+// Len returns the number of indexed employees.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Rebuild replaces the index with every active employee in the database.
+func (x *Index) Rebuild(ctx context.Context) error {
+	x.rebuilding.Lock()
+	defer x.rebuilding.Unlock()
+
+	x.mu.Lock()
+	x.touched = map[string]bool{}
+	x.mu.Unlock()
+
+	employees, err := x.store.Employees().ListByIDs(ctx, nil)
+	if err != nil {
+		x.mu.Lock()
+		x.touched = nil
+		x.mu.Unlock()
+		return err
+	}
+
+	fresh := NewIndex(x.store)
+	for _, e := range employees {
+		fresh.add(e)
+	}
+
+	x.mu.Lock()
+	x.docs, x.postings = fresh.docs, fresh.postings
+	touched := make([]string, 0, len(x.touched))
+	for id := range x.touched {
+		touched = append(touched, id)
+	}
+	x.touched = nil
+	x.mu.Unlock()
+
+	// A write refreshed while the rows were loading may be newer than what
+	// the load read
+	return x.Refresh(ctx, touched...)
+}
+
+// Run rebuilds the index every interval until ctx is done. Failures are
+// logged and retried on the next tick.
+func (x *Index) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := x.Rebuild(ctx); err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Search index rebuild failed", "error", err)
+			}
+		}
+	}
+}
+
+// Refresh reloads the given employees, dropping those that are deleted.
+func (x *Index) Refresh(ctx context.Context, ids ...string) error {
+	for len(ids) > 0 {
+		batch := ids[:min(len(ids), refreshBatch)]
+		ids = ids[len(batch):]
+
+		employees, err := x.store.Employees().ListByIDs(ctx, batch)
+		if err != nil {
+			return err
+		}
+		loaded := make(map[string]model.Employee, len(employees))
+		for _, e := range employees {
+			loaded[e.ID] = e
+		}
+
+		x.mu.Lock()
+		for _, id := range batch {
+			if x.touched != nil {
+				x.touched[id] = true
+			}
+			e, ok := loaded[id]
+			// Concurrent refreshes of one employee may finish out of order;
+			// never replace a newer version with an older one.
+			if doc := x.docs[id]; ok && doc != nil && doc.version > e.Version {
+				continue
+			}
+			x.remove(id)
+			if ok {
+				x.add(e)
+			}
+		}
+		x.mu.Unlock()
+	}
+	return nil
+}
+
+// RefreshDepartement reloads the indexed employees of a departement, e.g.
+// after it was renamed.
+func (x *Index) RefreshDepartement(ctx context.Context, departementID string) error {
+	x.mu.RLock()
+	var ids []string
+	for id, doc := range x.docs {
+		if doc.hit.DepartementID == departementID {
+			ids = append(ids, id)
+		}
+	}
+	x.mu.RUnlock()
+	return x.Refresh(ctx, ids...)
+}
+
+// add indexes e; the caller holds mu for writing.
+func (x *Index) add(e model.Employee) {
+	doc := &document{
+		hit: Hit{
+			ID:              e.ID,
+			EmployeeID:      e.EmployeeID,
+			Name:            e.Name,
+			DepartementID:   e.DepartementID,
+			DepartementName: e.DepartementName,
+			Address:         e.Address,
+			Position:        e.Position,
+			Status:          e.Status,
+		},
+		version: e.Version,
+	}
+
+	occurrences := map[string]posting{}
+	for i, f := range fields {
+		for _, term := range tokenize(f.value(e)) {
+			p := occurrences[term]
+			p.weight += f.weight
+			p.fields |= 1 << i
+			occurrences[term] = p
+		}
+	}
+	for term, p := range occurrences {
+		if x.postings[term] == nil {
+			x.postings[term] = map[string]posting{}
+		}
+		x.postings[term][e.ID] = p
+		doc.terms = append(doc.terms, term)
+	}
+	x.docs[e.ID] = doc
+}
+
+// remove drops an employee from the index; the caller holds mu for writing.
+func (x *Index) remove(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		delete(x.postings[term], id)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+	delete(x.docs, id)
+}
+
+type match struct {
+	score  float64
+	fields fieldSet
+}
+
+// Search returns the best limit employees matching every word of query, and
+// how many matched in total. A word matches indexed words that equal it,
+// start with it, or, unless it holds digits, are a typo or two away from it.
+// Rarer words and matches in the employee code or name rank higher.
+func (x *Index) Search(query string, limit int) ([]Hit, int) {
+	words := unique(tokenize(query))
+	if len(words) > maxQueryTerms {
+		words = words[:maxQueryTerms]
+	}
+	if len(words) == 0 {
+		return nil, 0
+	}
+	if limit <= 0 || limit > MaxLimit {
+		limit = DefaultLimit
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var matches map[string]match
+	for i, word := range words {
+		found := x.lookup(word)
+		if i == 0 {
+			matches = found
+			continue
+		}
+		for id, m := range matches {
+			f, ok := found[id]
+			if !ok {
+				delete(matches, id)
+				continue
+			}
+			matches[id] = match{score: m.score + f.score, fields: m.fields | f.fields}
+		}
+	}
+
+	hits := make([]Hit, 0, len(matches))
+	for id, m := range matches {
+		hit := x.docs[id].hit
+		hit.Score = math.Round(m.score*1000) / 1000
+		hit.Matched = m.fields.names()
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Name != hits[j].Name {
+			return hits[i].Name < hits[j].Name
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	total := len(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total
+}
+
+// lookup scores the employees matching one query word by their best
+// matching term; the caller holds mu.
+func (x *Index) lookup(word string) map[string]match {
+	found := map[string]match{}
+	n := float64(len(x.docs))
+	for term, postings := range x.postings {
+		similar := similarity(word, term)
+		if similar == 0 {
+			continue
+		}
+		idf := math.Log(1 + n/float64(len(postings)))
+		for id, p := range postings {
+			score := similar * p.weight * idf
+			if m, ok := found[id]; !ok || score > m.score {
+				found[id] = match{score: score, fields: m.fields | p.fields}
+			} else {
+				m.fields |= p.fields
+				found[id] = m
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize splits s into lower-cased words of letters and digits. Words
+// mixing both, like employee codes, are also indexed by their letter and
+// digit runs so "001" finds "EMP001".
+func tokenize(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := append([]string(nil), words...)
+	for _, word := range words {
+		runs := digitRuns(word)
+		if len(runs) > 1 {
+			tokens = append(tokens, runs...)
+		}
+	}
+	return tokens
+}
+
+// digitRuns splits word where it changes between letters and digits.
+func digitRuns(word string) []string {
+	var runs []string
+	start := 0
+	prev := false
+	for i, r := range word {
+		digit := unicode.IsDigit(r)
+		if i > 0 && digit != prev {
+			runs = append(runs, word[start:i])
+			start = i
+		}
+		prev = digit
+	}
+	return append(runs, word[start:])
+}
+
+func unique(words []string) []string {
+	seen := map[string]bool{}
+	result := words[:0:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// similarity rates how well an indexed term matches a query word, from 0
+// (no match) to 1 (equal).
+func similarity(word, term string) float64 {
+	if word == term {
+		return 1
+	}
+	w, t := []rune(word), []rune(term)
+	// Prefixes help while typing; the more of the term typed, the better
+	if len(w) >= 2 && strings.HasPrefix(term, word) {
+		return 0.5 + 0.4*float64(len(w))/float64(len(t))
+	}
+
+	// Typos are only forgiven in longer words; codes and numbers must be
+	// typed exactly
+	maxEdits := 0
+	switch {
+	case len(w) >= 8:
+		maxEdits = 2
+	case len(w) >= 4:
+		maxEdits = 1
+	}
+	if maxEdits == 0 || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+		return 0
+	}
+	d := editDistance(w, t, maxEdits)
+	if d > maxEdits {
+		return 0
+	}
+	return 0.8 - 0.2*float64(d)
+}
+
+// editDistance is the Damerau-Levenshtein (optimal string alignment)
+// distance of a and b, or bound+1 once it is known to exceed bound.
+func editDistance(a, b []rune, bound int) int {
+	if d := len(a) - len(b); d > bound || -d > bound {
+		return bound + 1
+	}
+
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	prevMin := 0
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		// A transposition reaches back two rows, so both must be over
+		if rowMin > bound && prevMin > bound {
+			return bound + 1
+		}
+		prevMin = rowMin
+		prev2, prev, cur = prev, cur, prev2
+	}
+	if prev[len(b)] > bound {
+		return bound + 1
+	}
+	return prev[len(b)]
+}
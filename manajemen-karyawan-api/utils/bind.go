@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report binding errors by the JSON name the client sent, not the Go name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(validation.JSONName)
+	}
+}
+
+// BindJSONStrict binds the JSON body into target. On failure it attaches a
+// validation error with per-field details to c, for ErrorMiddleware to
+// render, and returns it; the caller only has to return. The body itself is
+// never logged since it may hold credentials.
+func BindJSONStrict(c *gin.Context, target interface{}) error {
+	if err := c.ShouldBindJSON(target); err != nil {
+		slog.DebugContext(c.Request.Context(), "Bind error", "error", err)
+		bindErr := BindError(err)
+		c.Error(bindErr)
+		return bindErr
+	}
+	return nil
+}
+
+// BindError translates a gin binding error into a coded error.
+func BindError(err error) *apperr.Error {
+	var (
+		verrs   validator.ValidationErrors
+		typeErr *json.UnmarshalTypeError
+		syntax  *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &verrs):
+		return apperr.Validation(validation.FieldErrors(verrs)...)
+	case errors.As(err, &typeErr):
+		return apperr.Invalid(typeErr.Field, "type", fmt.Sprintf("%s must be %s", typeErr.Field, jsonKind(typeErr.Type)))
+	case errors.As(err, &syntax), errors.Is(err, io.ErrUnexpectedEOF):
+		return apperr.BadRequest("request body is not valid JSON")
+	case errors.Is(err, io.EOF):
+		return apperr.BadRequest("request body is required")
+	}
+	return apperr.BadRequest("invalid request body")
+}
+
+// jsonKind names the JSON value a Go type is decoded from.
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a " + t.String()
+}
+
+// BindPayload binds the JSON body like BindJSONStrict, then checks its
+// validate rules for op. Either failure is attached to c and returned.
+func BindPayload(c *gin.Context, target interface{}, op validation.Op) error {
+	if err := BindJSONStrict(c, target); err != nil {
+		return err
+	}
+	if err := validation.Struct(c.Request.Context(), op, target); err != nil {
+		c.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+)
+
+// Keyset is how a listing pages with cursors: rows are ordered by one sort
+// field and then by ID, so each page starts strictly after the last row of
+// the previous one.
+type Keyset struct {
+	// ID is a unique column breaking ties between equal sort values.
+	ID string
+	// Default is the sort used when the request has no sort_by.
+	Default SortField
+}
+
+// PageInfo describes the page a listing returned.
+type PageInfo struct {
+	// Total is nil when the listing was not counted.
+	Total *int
+	// NextCursor continues a cursor listing; empty on its last page.
+	NextCursor string
+
+	keyset  bool
+	perPage int
+}
+
+// cursor is the position after the last row of a page. It is sent to the
+// client base64 encoded and treated as opaque there.
+type cursor struct {
+	Sort  string      `json:"s"`
+	Order string      `json:"o"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+// Page is the SQL of one page of a listing. When the request has a cursor
+// ("" for the first page) it pages by keyset, which stays stable while rows
+// are inserted and skips the COUNT unless with_total is set; otherwise it
+// uses LIMIT/OFFSET.
+type Page struct {
+	// Select holds extra columns to read after each row's own ones.
+	Select string
+	// Where is an "AND ..." condition for rows after the cursor.
+	Where   string
+	Args    []interface{}
+	OrderBy string
+
+	withTotal  bool
+	pagination Pagination
+	keyset     bool
+	perPage    int
+	sort       SortField
+	keys       []cursor
+}
+
+// BuildPage resolves the sort and pagination of params against fields.
+func BuildPage(params QueryParams, fields Fields, ks Keyset) (*Page, error) {
+	p := &Page{withTotal: params.WithTotal}
+	if params.Cursor == nil {
+		sortBy := params.SortBy
+		if params.LenientSort {
+			sortBy = fields.known(sortBy)
+		}
+		orderBy, err := BuildSortSQL(sortBy, fields)
+		if err != nil {
+			return nil, err
+		}
+		p.OrderBy = orderBy
+		p.pagination = BuildPagination(params.Page, params.PerPage)
+		return p, nil
+	}
+
+	p.keyset = true
+	p.perPage = 10
+	if params.PerPage != nil && *params.PerPage > 0 {
+		p.perPage = *params.PerPage
+	}
+
+	p.sort = ks.Default
+	switch len(params.SortBy) {
+	case 0:
+	case 1:
+		p.sort = params.SortBy[0]
+	default:
+		return nil, apperr.Invalid("sort_by", "max", "sort_by takes a single key when paging with a cursor")
+	}
+	if _, err := BuildSortSQL([]SortField{p.sort}, fields); err != nil {
+		return nil, err
+	}
+	field := fields[p.sort.Key]
+	if field.Nullable {
+		return nil, apperr.Invalid("sort_by[0].key", "cursor", fmt.Sprintf("%s may be empty and cannot be used with a cursor", p.sort.Key))
+	}
+
+	order := strings.ToUpper(p.sort.Order)
+	if order == "" {
+		order = "ASC"
+	}
+	p.sort.Order = strings.ToLower(order)
+	p.Select = ", " + field.Column + ", " + ks.ID
+	p.OrderBy = fmt.Sprintf("ORDER BY %s %s, %s %s", field.Column, order, ks.ID, order)
+
+	if *params.Cursor == "" {
+		return p, nil
+	}
+	after, err := decodeCursor(*params.Cursor, field)
+	if err != nil {
+		return nil, apperr.Invalid("cursor", "invalid", "cursor is not valid, start again without one")
+	}
+	if after.Sort != p.sort.Key || after.Order != p.sort.Order {
+		return nil, apperr.Invalid("cursor", "mismatch", "cursor was issued for a different sort_by")
+	}
+
+	cmp := ">"
+	if order == "DESC" {
+		cmp = "<"
+	}
+	p.Where = fmt.Sprintf("AND (%s %s ? OR (%s = ? AND %s %s ?))", field.Column, cmp, field.Column, ks.ID, cmp)
+	p.Args = []interface{}{after.Value, after.Value, after.ID}
+	return p, nil
+}
+
+// Limit appends the LIMIT, and OFFSET if any, of the page to query. A
+// keyset page reads one row more than it returns to learn whether another
+// page follows.
+func (p *Page) Limit(query string, args []interface{}) (string, []interface{}) {
+	if p.keyset {
+		return query + " LIMIT ?", append(args, p.perPage+1)
+	}
+	if !p.pagination.Use {
+		return query, args
+	}
+	return query + " LIMIT ? OFFSET ?", append(args, p.pagination.Limit, p.pagination.Offset)
+}
+
+// PageRows reads a page query; on keyset pages Scan also reads the Select
+// columns into the page's cursor positions.
+type PageRows struct {
+	*sql.Rows
+	page *Page
+}
+
+func (p *Page) Rows(rows *sql.Rows) *PageRows {
+	return &PageRows{Rows: rows, page: p}
+}
+
+func (r *PageRows) Scan(dest ...interface{}) error {
+	if !r.page.keyset {
+		return r.Rows.Scan(dest...)
+	}
+
+	var (
+		value interface{}
+		id    string
+	)
+	if err := r.Rows.Scan(append(dest, &value, &id)...); err != nil {
+		return err
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	r.page.keys = append(r.page.keys, cursor{Sort: r.page.sort.Key, Order: r.page.sort.Order, Value: value, ID: id})
+	return nil
+}
+
+// Keep returns how many of the n rows read belong to the page.
+func (p *Page) Keep(n int) int {
+	if p.keyset && n > p.perPage {
+		return p.perPage
+	}
+	return n
+}
+
+// Counted reports whether the listing has to run its COUNT query.
+func (p *Page) Counted() bool {
+	return !p.keyset || p.withTotal
+}
+
+// Info describes the page once its rows are read; total is only reported
+// when Counted.
+func (p *Page) Info(total int) PageInfo {
+	info := PageInfo{keyset: p.keyset, perPage: p.perPage}
+	if p.Counted() {
+		info.Total = &total
+	}
+	if p.keyset && len(p.keys) > p.perPage {
+		info.NextCursor = encodeCursor(p.keys[p.perPage-1])
+	}
+	return info
+}
+
+// Meta builds the "meta" object of a list response.
+func (info PageInfo) Meta(params QueryParams) map[string]interface{} {
+	if !info.keyset {
+		total := 0
+		if info.Total != nil {
+			total = *info.Total
+		}
+		return BuildMeta(MetaParams{Page: params.Page, PerPage: params.PerPage, Total: total, SortBy: params.SortBy})
+	}
+
+	meta := map[string]interface{}{
+		"per_page": info.perPage,
+		"has_more": info.NextCursor != "",
+	}
+	if info.NextCursor != "" {
+		meta["next_cursor"] = info.NextCursor
+	}
+	if info.Total != nil {
+		meta["total"] = *info.Total
+	}
+	if len(params.SortBy) > 0 {
+		meta["sort_by"] = params.SortBy
+	}
+	return meta
+}
+
+func encodeCursor(c cursor) string {
+	if t, ok := c.Value.(time.Time); ok {
+		c.Value = t.UTC().Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor restores a cursor, turning its value back into the Go type
+// the column compares against.
+func decodeCursor(s string, field Field) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, err
+	}
+	if c.ID == "" {
+		return c, fmt.Errorf("cursor without id")
+	}
+
+	switch v := c.Value.(type) {
+	case json.Number:
+		c.Value, err = v.Int64()
+	case string:
+		if field.Type == DateField {
+			c.Value, err = time.Parse(time.RFC3339Nano, v)
+		}
+	default:
+		err = fmt.Errorf("cursor value of type %T", v)
+	}
+	return c, err
+}
@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+)
+
+var testKeyset = Keyset{ID: "e.id", Default: SortField{Key: "name", Order: "asc"}}
+
+func intPtr(n int) *int { return &n }
+
+func strPtr(s string) *string { return &s }
+
+func TestBuildPageOffset(t *testing.T) {
+	params := QueryParams{
+		Page:    intPtr(3),
+		PerPage: intPtr(20),
+		SortBy:  []SortField{{Key: "age", Order: "desc"}},
+	}
+	p, err := BuildPage(params, testFields, testKeyset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.OrderBy != "ORDER BY e.age DESC" || p.Select != "" || p.Where != "" {
+		t.Errorf("page = %+v", p)
+	}
+	query, args := p.Limit("SELECT", []interface{}{"x"})
+	if query != "SELECT LIMIT ? OFFSET ?" || !reflect.DeepEqual(args, []interface{}{"x", 20, 40}) {
+		t.Errorf("Limit = %q %v", query, args)
+	}
+	if !p.Counted() {
+		t.Error("offset pages are always counted")
+	}
+	if info := p.Info(95); info.Total == nil || *info.Total != 95 || info.NextCursor != "" {
+		t.Errorf("Info = %+v", info)
+	}
+}
+
+func TestBuildPageLenientSort(t *testing.T) {
+	params := QueryParams{
+		SortBy:      []SortField{{Key: "clock"}, {Key: "name", Order: "desc"}},
+		LenientSort: true,
+	}
+	p, err := BuildPage(params, testFields, testKeyset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.OrderBy != "ORDER BY e.name DESC" {
+		t.Errorf("OrderBy = %q", p.OrderBy)
+	}
+
+	// Cursors are newer than the lenient clients and stay strict
+	params.SortBy = []SortField{{Key: "clock"}}
+	params.Cursor = strPtr("")
+	_, err = BuildPage(params, testFields, testKeyset)
+	assertFieldErrors(t, err, []apperr.FieldError{{Field: "sort_by[0].key", Code: "unknown_field"}})
+}
+
+func TestBuildPageKeyset(t *testing.T) {
+	tests := []struct {
+		name    string
+		sortBy  []SortField
+		after   cursor
+		orderBy string
+		where   string
+		args    []interface{}
+	}{
+		{
+			name:    "default sort, first page",
+			orderBy: "ORDER BY e.name ASC, e.id ASC",
+		},
+		{
+			name:    "ties are broken on id in the same direction",
+			sortBy:  []SortField{{Key: "age", Order: "DESC"}},
+			orderBy: "ORDER BY e.age DESC, e.id DESC",
+		},
+		{
+			name:    "ascending after a cursor",
+			after:   cursor{Sort: "name", Order: "asc", Value: "Budi", ID: "b2"},
+			orderBy: "ORDER BY e.name ASC, e.id ASC",
+			where:   "AND (e.name > ? OR (e.name = ? AND e.id > ?))",
+			args:    []interface{}{"Budi", "Budi", "b2"},
+		},
+		{
+			name:    "descending after a cursor",
+			sortBy:  []SortField{{Key: "age", Order: "desc"}},
+			after:   cursor{Sort: "age", Order: "desc", Value: 41, ID: "a9"},
+			orderBy: "ORDER BY e.age DESC, e.id DESC",
+			where:   "AND (e.age < ? OR (e.age = ? AND e.id < ?))",
+			args:    []interface{}{int64(41), int64(41), "a9"},
+		},
+		{
+			name:    "dates round trip as times",
+			sortBy:  []SortField{{Key: "day", Order: "desc"}},
+			after:   cursor{Sort: "day", Order: "desc", Value: time.Date(2024, 3, 10, 1, 2, 3, 4000, time.FixedZone("WIB", 7*3600)), ID: "h1"},
+			orderBy: "ORDER BY h.day DESC, e.id DESC",
+			where:   "AND (h.day < ? OR (h.day = ? AND e.id < ?))",
+			args: []interface{}{
+				time.Date(2024, 3, 9, 18, 2, 3, 4000, time.UTC),
+				time.Date(2024, 3, 9, 18, 2, 3, 4000, time.UTC),
+				"h1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ""
+			if tt.after.ID != "" {
+				c = encodeCursor(tt.after)
+			}
+			params := QueryParams{SortBy: tt.sortBy, Cursor: &c, PerPage: intPtr(5), Page: intPtr(7)}
+			p, err := BuildPage(params, testFields, testKeyset)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.OrderBy != tt.orderBy {
+				t.Errorf("OrderBy = %q, want %q", p.OrderBy, tt.orderBy)
+			}
+			if p.Where != tt.where {
+				t.Errorf("Where = %q, want %q", p.Where, tt.where)
+			}
+			if !reflect.DeepEqual(p.Args, tt.args) {
+				t.Errorf("Args = %#v, want %#v", p.Args, tt.args)
+			}
+			// page is ignored; one extra row tells whether more follow
+			query, args := p.Limit("SELECT", nil)
+			if query != "SELECT LIMIT ?" || !reflect.DeepEqual(args, []interface{}{6}) {
+				t.Errorf("Limit = %q %v", query, args)
+			}
+		})
+	}
+}
+
+func TestBuildPageKeysetErrors(t *testing.T) {
+	nameCursor := encodeCursor(cursor{Sort: "name", Order: "asc", Value: "Budi", ID: "b2"})
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		sortBy []SortField
+		cursor string
+		want   apperr.FieldError
+	}{
+		{
+			name:   "several sort keys",
+			sortBy: []SortField{{Key: "name"}, {Key: "age"}},
+			want:   apperr.FieldError{Field: "sort_by", Code: "max"},
+		},
+		{
+			name:   "nullable sort key",
+			sortBy: []SortField{{Key: "position"}},
+			want:   apperr.FieldError{Field: "sort_by[0].key", Code: "cursor"},
+		},
+		{
+			name:   "unknown sort key",
+			sortBy: []SortField{{Key: "salary"}},
+			want:   apperr.FieldError{Field: "sort_by[0].key", Code: "unknown_field"},
+		},
+		{
+			name:   "not base64",
+			cursor: "%%%",
+			want:   apperr.FieldError{Field: "cursor", Code: "invalid"},
+		},
+		{
+			name:   "not JSON",
+			cursor: raw("name:Budi"),
+			want:   apperr.FieldError{Field: "cursor", Code: "invalid"},
+		},
+		{
+			name:   "without id",
+			cursor: raw(`{"s":"name","o":"asc","v":"Budi"}`),
+			want:   apperr.FieldError{Field: "cursor", Code: "invalid"},
+		},
+		{
+			name:   "value of the wrong kind",
+			cursor: raw(`{"s":"name","o":"asc","v":{"x":1},"id":"b2"}`),
+			want:   apperr.FieldError{Field: "cursor", Code: "invalid"},
+		},
+		{
+			name:   "fractional number",
+			sortBy: []SortField{{Key: "age"}},
+			cursor: raw(`{"s":"age","o":"asc","v":1.5,"id":"b2"}`),
+			want:   apperr.FieldError{Field: "cursor", Code: "invalid"},
+		},
+		{
+			name:   "bad date",
+			sortBy: []SortField{{Key: "day"}},
+			cursor: raw(`{"s":"day","o":"asc","v":"yesterday","id":"b2"}`),
+			want:   apperr.FieldError{Field: "cursor", Code: "invalid"},
+		},
+		{
+			name:   "issued for another key",
+			sortBy: []SortField{{Key: "age"}},
+			cursor: nameCursor,
+			want:   apperr.FieldError{Field: "cursor", Code: "mismatch"},
+		},
+		{
+			name:   "issued for another order",
+			sortBy: []SortField{{Key: "name", Order: "desc"}},
+			cursor: nameCursor,
+			want:   apperr.FieldError{Field: "cursor", Code: "mismatch"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := QueryParams{SortBy: tt.sortBy, Cursor: &tt.cursor}
+			p, err := BuildPage(params, testFields, testKeyset)
+			if p != nil {
+				t.Errorf("got a page alongside an error")
+			}
+			assertFieldErrors(t, err, []apperr.FieldError{tt.want})
+		})
+	}
+}
+
+func TestPageInfo(t *testing.T) {
+	read := []cursor{
+		{Sort: "name", Order: "asc", Value: "Ani", ID: "1"},
+		{Sort: "name", Order: "asc", Value: "Budi", ID: "3"},
+		{Sort: "name", Order: "asc", Value: "Budi", ID: "7"},
+	}
+
+	tests := []struct {
+		name      string
+		withTotal bool
+		keys      []cursor
+		total     *int
+		next      *cursor
+		meta      map[string]interface{}
+	}{
+		{
+			name: "more rows follow",
+			keys: read,
+			next: &read[1],
+			meta: map[string]interface{}{"per_page": 2, "has_more": true},
+		},
+		{
+			name: "last page",
+			keys: read[:2],
+			meta: map[string]interface{}{"per_page": 2, "has_more": false},
+		},
+		{
+			name:      "with total",
+			withTotal: true,
+			keys:      read[:1],
+			total:     intPtr(11),
+			meta:      map[string]interface{}{"per_page": 2, "has_more": false, "total": 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ""
+			params := QueryParams{Cursor: &c, PerPage: intPtr(2), WithTotal: tt.withTotal}
+			p, err := BuildPage(params, testFields, testKeyset)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			p.keys = tt.keys
+
+			if p.Counted() != tt.withTotal {
+				t.Errorf("Counted = %v, want %v", p.Counted(), tt.withTotal)
+			}
+			if keep := p.Keep(len(tt.keys)); keep != min(len(tt.keys), 2) {
+				t.Errorf("Keep = %d", keep)
+			}
+
+			info := p.Info(11)
+			if !reflect.DeepEqual(info.Total, tt.total) {
+				t.Errorf("Total = %v, want %v", info.Total, tt.total)
+			}
+			if tt.next == nil {
+				if info.NextCursor != "" {
+					t.Errorf("NextCursor = %q on the last page", info.NextCursor)
+				}
+			} else {
+				got, err := decodeCursor(info.NextCursor, testFields["name"])
+				if err != nil || got != *tt.next {
+					t.Errorf("NextCursor decodes to %+v, %v, want %+v", got, err, *tt.next)
+				}
+				tt.meta["next_cursor"] = info.NextCursor
+			}
+			if meta := info.Meta(params); !reflect.DeepEqual(meta, tt.meta) {
+				t.Errorf("Meta = %v, want %v", meta, tt.meta)
+			}
+		})
+	}
+}
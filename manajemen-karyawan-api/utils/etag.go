@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"manajemen-karyawan-api/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetETag sends a row version as the response's entity tag.
+func SetETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// IfMatch returns the row version a write is conditioned on, taken from the
+// If-Match header, or 0 for an unconditional write when the header is absent
+// or "*". A header that is not a single ETag from SetETag is attached to c as
+// a bad request and returned; the caller only has to return.
+func IfMatch(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	// Proxies that compress responses may weaken the tag; the version is
+	// still the same, so accept it.
+	tag := strings.TrimPrefix(header, "W/")
+	var version int
+	unquoted, err := strconv.Unquote(tag)
+	if err == nil && strings.HasPrefix(tag, `"`) {
+		version, err = strconv.Atoi(unquoted)
+	}
+	if err != nil || version <= 0 {
+		bad := apperr.BadRequest(`If-Match must be a single ETag as returned by GET, e.g. "3"`)
+		c.Error(bad)
+		return 0, bad
+	}
+	return version, nil
+}
@@ -0,0 +1,374 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+	"manajemen-karyawan-api/validation"
+)
+
+// Filter is the "filter" object of a list request. Each key is a field name,
+// optionally followed by an operator, and all conditions must hold:
+//
+//	{
+//	  "status.in": ["active", "resigned"],
+//	  "dateAttendance.between": ["2024-01-01", "2024-01-31"],
+//	  "position.isnull": false,
+//	  "or": [{"name": "budi"}, {"employeeID.eq": "EMP001"}]
+//	}
+//
+// "or" holds filter objects of which at least one must match; they may nest
+// their own "or". Without an operator text fields use like and every other
+// field uses eq, which keeps the older {"name": "budi"} form working. Forms
+// send "" for filters the user left blank, so an empty string matches
+// everything.
+type Filter map[string]json.RawMessage
+
+type FieldType int
+
+const (
+	TextField FieldType = iota
+	IntField
+	// DateField values are YYYY-MM-DD days; the column is a timestamp, so
+	// each day becomes a half-open range in the request's location.
+	DateField
+	// ClockField values are HH:MM:SS times of day.
+	ClockField
+	EnumField
+)
+
+// Field describes a column clients may filter and sort on.
+type Field struct {
+	Column string
+	Type   FieldType
+	// Nullable fields accept the isnull operator.
+	Nullable bool
+	// Values maps what a client may send for an EnumField to the stored value.
+	Values map[string]interface{}
+}
+
+// Fields is the registry of filterable fields of one listing, keyed by the
+// JSON name clients use.
+type Fields map[string]Field
+
+const (
+	opEq      = "eq"
+	opNeq     = "neq"
+	opGt      = "gt"
+	opGte     = "gte"
+	opLt      = "lt"
+	opLte     = "lte"
+	opLike    = "like"
+	opIn      = "in"
+	opBetween = "between"
+	opIsNull  = "isnull"
+)
+
+const (
+	maxFilterDepth = 3
+	maxInValues    = 100
+)
+
+var (
+	rangeOps  = map[string]bool{opGt: true, opGte: true, opLt: true, opLte: true, opBetween: true}
+	filterOps = map[string]bool{
+		opEq: true, opNeq: true, opLike: true, opIn: true, opIsNull: true,
+		opGt: true, opGte: true, opLt: true, opLte: true, opBetween: true,
+	}
+)
+
+// BuildFilterSQL turns filter into an "AND ..." clause over the columns of
+// fields, with dates read in loc (UTC when nil). Unknown fields or operators
+// and values of the wrong type fail with a validation error naming every bad
+// key, so a typo never silently widens the result.
+func BuildFilterSQL(filter Filter, fields Fields, loc *time.Location) (string, []interface{}, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	b := filterBuilder{fields: fields, loc: loc}
+	clause := b.group(filter, "filter", 1)
+	if len(b.errs) > 0 {
+		return "", nil, apperr.Validation(b.errs...)
+	}
+	if clause == "" {
+		return "", nil, nil
+	}
+	return "AND " + clause, b.args, nil
+}
+
+type filterBuilder struct {
+	fields Fields
+	loc    *time.Location
+	args   []interface{}
+	errs   []apperr.FieldError
+}
+
+func (b *filterBuilder) fail(path, code, message string) {
+	b.errs = append(b.errs, apperr.FieldError{Field: path, Code: code, Message: message})
+}
+
+// group ANDs the conditions of one filter object.
+func (b *filterBuilder) group(filter Filter, path string, depth int) string {
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	// Sorted so the same filter always produces the same SQL
+	sort.Strings(keys)
+
+	var clauses []string
+	for _, key := range keys {
+		var clause string
+		if key == "or" {
+			clause = b.or(filter[key], path+".or", depth)
+		} else {
+			clause = b.condition(key, filter[key], path+"."+key)
+		}
+		if clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+func (b *filterBuilder) or(raw json.RawMessage, path string, depth int) string {
+	if depth >= maxFilterDepth {
+		b.fail(path, "depth", fmt.Sprintf("%s nests too deep, at most %d levels are allowed", path, maxFilterDepth))
+		return ""
+	}
+	var branches []Filter
+	if err := json.Unmarshal(raw, &branches); err != nil || len(branches) == 0 {
+		b.fail(path, "type", path+" must be a non-empty array of filter objects")
+		return ""
+	}
+
+	parts := make([]string, 0, len(branches))
+	for i, branch := range branches {
+		branchPath := fmt.Sprintf("%s[%d]", path, i)
+		if len(branch) == 0 {
+			b.fail(branchPath, "required", branchPath+" must not be empty")
+			continue
+		}
+		if clause := b.group(branch, branchPath, depth+1); clause != "" {
+			parts = append(parts, "("+clause+")")
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func (b *filterBuilder) condition(key string, raw json.RawMessage, path string) string {
+	name, op, _ := strings.Cut(key, ".")
+	field, ok := b.fields[name]
+	if !ok {
+		b.fail(path, "unknown_field", fmt.Sprintf("%s is not a filterable field, use one of: %s", name, strings.Join(b.fields.names(), ", ")))
+		return ""
+	}
+	if op == "" {
+		op = opEq
+		if field.Type == TextField {
+			op = opLike
+		}
+	}
+	if !filterOps[op] {
+		b.fail(path, "unknown_operator", fmt.Sprintf("%s is not a filter operator, use eq, neq, gt, gte, lt, lte, like, in, between or isnull", op))
+		return ""
+	}
+	if (op == opLike && field.Type != TextField) || (rangeOps[op] && field.Type == EnumField) || (op == opIsNull && !field.Nullable) {
+		b.fail(path, "operator", fmt.Sprintf("%s does not support %s", name, op))
+		return ""
+	}
+
+	if op != opIn && op != opBetween && op != opIsNull && string(raw) == `""` {
+		return ""
+	}
+
+	col := field.Column
+	switch op {
+	case opIsNull:
+		var isNull bool
+		if err := json.Unmarshal(raw, &isNull); err != nil {
+			b.fail(path, "type", path+" must be true or false")
+			return ""
+		}
+		if isNull {
+			return col + " IS NULL"
+		}
+		return col + " IS NOT NULL"
+
+	case opIn:
+		values, ok := b.list(field, raw, path)
+		if !ok {
+			return ""
+		}
+		if len(values) == 0 || len(values) > maxInValues {
+			b.fail(path, "type", fmt.Sprintf("%s must list 1 to %d values", path, maxInValues))
+			return ""
+		}
+		if field.Type == DateField {
+			days := make([]string, len(values))
+			for i, v := range values {
+				days[i] = b.dayRange(col, v.(time.Time))
+			}
+			return "(" + strings.Join(days, " OR ") + ")"
+		}
+		b.args = append(b.args, values...)
+		return fmt.Sprintf("%s IN (%s)", col, Placeholders(len(values)))
+
+	case opBetween:
+		values, ok := b.list(field, raw, path)
+		if !ok {
+			return ""
+		}
+		if len(values) != 2 {
+			b.fail(path, "type", path+" must be an array of exactly two values")
+			return ""
+		}
+		if field.Type == DateField {
+			b.args = append(b.args, values[0], values[1].(time.Time).AddDate(0, 0, 1))
+			return fmt.Sprintf("(%s >= ? AND %s < ?)", col, col)
+		}
+		b.args = append(b.args, values...)
+		return fmt.Sprintf("%s BETWEEN ? AND ?", col)
+	}
+
+	value, err := field.parse(raw, b.loc)
+	if err != nil {
+		b.fail(path, "type", fmt.Sprintf("%s %s", path, err))
+		return ""
+	}
+
+	if op == opLike {
+		b.args = append(b.args, "%"+strings.ToLower(value.(string))+"%")
+		return fmt.Sprintf("LOWER(%s) LIKE ?", col)
+	}
+
+	if field.Type == DateField {
+		day := value.(time.Time)
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case opEq:
+			return b.dayRange(col, day)
+		case opNeq:
+			b.args = append(b.args, day, next)
+			return fmt.Sprintf("(%s < ? OR %s >= ?)", col, col)
+		case opGt:
+			b.args = append(b.args, next)
+			return col + " >= ?"
+		case opLte:
+			b.args = append(b.args, next)
+			return col + " < ?"
+		}
+	}
+
+	b.args = append(b.args, value)
+	return fmt.Sprintf("%s %s ?", col, comparison[op])
+}
+
+var comparison = map[string]string{
+	opEq: "=", opNeq: "<>", opGt: ">", opGte: ">=", opLt: "<", opLte: "<=",
+}
+
+// dayRange matches col against the whole day starting at day.
+func (b *filterBuilder) dayRange(col string, day time.Time) string {
+	b.args = append(b.args, day, day.AddDate(0, 0, 1))
+	return fmt.Sprintf("(%s >= ? AND %s < ?)", col, col)
+}
+
+// list parses a JSON array of values of field's type.
+func (b *filterBuilder) list(field Field, raw json.RawMessage, path string) ([]interface{}, bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		b.fail(path, "type", path+" must be an array")
+		return nil, false
+	}
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := field.parse(item, b.loc)
+		if err != nil {
+			b.fail(fmt.Sprintf("%s[%d]", path, i), "type", fmt.Sprintf("%s[%d] %s", path, i, err))
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
+
+// parse converts one JSON value to the Go value bound for the column.
+// Numbers and strings are interchangeable, so {"attendanceType": "1"} from
+// older clients still works.
+func (f Field) parse(raw json.RawMessage, loc *time.Location) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("is not valid JSON")
+	}
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("must be a string or number")
+	}
+
+	switch f.Type {
+	case IntField:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return n, nil
+	case DateField:
+		day, err := time.ParseInLocation(validation.DateLayout, s, loc)
+		if err != nil {
+			return nil, fmt.Errorf("must be a date as YYYY-MM-DD")
+		}
+		return day, nil
+	case ClockField:
+		if _, err := time.Parse(validation.ClockLayout, s); err != nil || len(s) != len(validation.ClockLayout) {
+			return nil, fmt.Errorf("must be a time as HH:MM:SS")
+		}
+		return s, nil
+	case EnumField:
+		stored, ok := f.Values[s]
+		if !ok {
+			allowed := make([]string, 0, len(f.Values))
+			for k := range f.Values {
+				allowed = append(allowed, k)
+			}
+			sort.Strings(allowed)
+			return nil, fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+		}
+		return stored, nil
+	}
+	return s, nil
+}
+
+// known drops the keys of sortBy that are not in fields, such as the
+// computed columns the Vue tables let users click on.
+func (fields Fields) known(sortBy []SortField) []SortField {
+	var kept []SortField
+	for _, s := range sortBy {
+		if _, ok := fields[s.Key]; ok {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+func (fields Fields) names() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
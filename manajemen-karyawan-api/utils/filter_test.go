@@ -0,0 +1,398 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+)
+
+var testFields = Fields{
+	"name":     {Column: "e.name"},
+	"position": {Column: "e.position", Nullable: true},
+	"age":      {Column: "e.age", Type: IntField},
+	"day":      {Column: "h.day", Type: DateField},
+	"maxClock": {Column: "d.max_clock", Type: ClockField},
+	"status": {Column: "e.status", Type: EnumField, Values: map[string]interface{}{
+		"active": "active", "resigned": "resigned",
+	}},
+	"type": {Column: "h.type", Type: EnumField, Values: map[string]interface{}{
+		"in": 1, "out": 2, "1": 1, "2": 2,
+	}},
+}
+
+func filterOf(t *testing.T, raw string) Filter {
+	t.Helper()
+	var f Filter
+	if err := json.Unmarshal([]byte(raw), &f); err != nil {
+		t.Fatalf("bad test filter %s: %v", raw, err)
+	}
+	return f
+}
+
+func day(s string, loc *time.Location) time.Time {
+	d, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestBuildFilterSQL(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+
+	tests := []struct {
+		name   string
+		filter string
+		loc    *time.Location
+		sql    string
+		args   []interface{}
+	}{
+		{
+			name:   "empty",
+			filter: `{}`,
+		},
+		{
+			name:   "blank values are ignored",
+			filter: `{"name": "", "age": ""}`,
+		},
+		{
+			name:   "text without operator is like",
+			filter: `{"name": "Budi"}`,
+			sql:    "AND LOWER(e.name) LIKE ?",
+			args:   []interface{}{"%budi%"},
+		},
+		{
+			name:   "int without operator is eq",
+			filter: `{"age": 30}`,
+			sql:    "AND e.age = ?",
+			args:   []interface{}{30},
+		},
+		{
+			name:   "number sent as string",
+			filter: `{"age.gte": "30"}`,
+			sql:    "AND e.age >= ?",
+			args:   []interface{}{30},
+		},
+		{
+			name:   "enum maps to stored value",
+			filter: `{"type": "in"}`,
+			sql:    "AND h.type = ?",
+			args:   []interface{}{1},
+		},
+		{
+			name:   "enum sent as number",
+			filter: `{"type.neq": 2}`,
+			sql:    "AND h.type <> ?",
+			args:   []interface{}{2},
+		},
+		{
+			name:   "in",
+			filter: `{"status.in": ["active", "resigned"]}`,
+			sql:    "AND e.status IN (?, ?)",
+			args:   []interface{}{"active", "resigned"},
+		},
+		{
+			name:   "between ints",
+			filter: `{"age.between": [20, 30]}`,
+			sql:    "AND e.age BETWEEN ? AND ?",
+			args:   []interface{}{20, 30},
+		},
+		{
+			name:   "clock",
+			filter: `{"maxClock.lt": "09:00:00"}`,
+			sql:    "AND d.max_clock < ?",
+			args:   []interface{}{"09:00:00"},
+		},
+		{
+			name:   "isnull",
+			filter: `{"position.isnull": true}`,
+			sql:    "AND e.position IS NULL",
+		},
+		{
+			name:   "is not null",
+			filter: `{"position.isnull": false}`,
+			sql:    "AND e.position IS NOT NULL",
+		},
+		{
+			name:   "date eq is the whole day",
+			filter: `{"day": "2024-03-10"}`,
+			sql:    "AND (h.day >= ? AND h.day < ?)",
+			args:   []interface{}{day("2024-03-10", time.UTC), day("2024-03-11", time.UTC)},
+		},
+		{
+			name:   "date in the request location",
+			filter: `{"day.eq": "2024-03-10"}`,
+			loc:    jakarta,
+			sql:    "AND (h.day >= ? AND h.day < ?)",
+			args:   []interface{}{day("2024-03-10", jakarta), day("2024-03-11", jakarta)},
+		},
+		{
+			name:   "date neq",
+			filter: `{"day.neq": "2024-03-10"}`,
+			sql:    "AND (h.day < ? OR h.day >= ?)",
+			args:   []interface{}{day("2024-03-10", time.UTC), day("2024-03-11", time.UTC)},
+		},
+		{
+			name:   "date gt starts the next day",
+			filter: `{"day.gt": "2024-03-10"}`,
+			sql:    "AND h.day >= ?",
+			args:   []interface{}{day("2024-03-11", time.UTC)},
+		},
+		{
+			name:   "date gte and lte include both days",
+			filter: `{"day.gte": "2024-03-01", "day.lte": "2024-03-31"}`,
+			sql:    "AND h.day >= ? AND h.day < ?",
+			args:   []interface{}{day("2024-03-01", time.UTC), day("2024-04-01", time.UTC)},
+		},
+		{
+			name:   "date lt",
+			filter: `{"day.lt": "2024-03-10"}`,
+			sql:    "AND h.day < ?",
+			args:   []interface{}{day("2024-03-10", time.UTC)},
+		},
+		{
+			name:   "date between includes the last day",
+			filter: `{"day.between": ["2024-03-01", "2024-03-31"]}`,
+			sql:    "AND (h.day >= ? AND h.day < ?)",
+			args:   []interface{}{day("2024-03-01", time.UTC), day("2024-04-01", time.UTC)},
+		},
+		{
+			name:   "date in",
+			filter: `{"day.in": ["2024-03-01", "2024-03-05"]}`,
+			sql:    "AND ((h.day >= ? AND h.day < ?) OR (h.day >= ? AND h.day < ?))",
+			args: []interface{}{
+				day("2024-03-01", time.UTC), day("2024-03-02", time.UTC),
+				day("2024-03-05", time.UTC), day("2024-03-06", time.UTC),
+			},
+		},
+		{
+			name:   "keys are sorted",
+			filter: `{"status": "active", "age.lt": 40, "name": "budi"}`,
+			sql:    "AND e.age < ? AND LOWER(e.name) LIKE ? AND e.status = ?",
+			args:   []interface{}{40, "%budi%", "active"},
+		},
+		{
+			name:   "or group",
+			filter: `{"status": "active", "or": [{"name": "budi"}, {"age.gt": 50, "position.isnull": true}]}`,
+			sql:    "AND ((LOWER(e.name) LIKE ?) OR (e.age > ? AND e.position IS NULL)) AND e.status = ?",
+			args:   []interface{}{"%budi%", 50, "active"},
+		},
+		{
+			name:   "nested or",
+			filter: `{"or": [{"name": "a"}, {"or": [{"name": "b"}, {"name": "c"}]}]}`,
+			sql:    "AND ((LOWER(e.name) LIKE ?) OR (((LOWER(e.name) LIKE ?) OR (LOWER(e.name) LIKE ?))))",
+			args:   []interface{}{"%a%", "%b%", "%c%"},
+		},
+		{
+			name:   "or of blank branches",
+			filter: `{"or": [{"name": ""}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := BuildFilterSQL(filterOf(t, tt.filter), testFields, tt.loc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestBuildFilterSQLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		// Field and code of every expected error, in order
+		want []apperr.FieldError
+	}{
+		{
+			name:   "unknown field",
+			filter: `{"salary": 1}`,
+			want:   []apperr.FieldError{{Field: "filter.salary", Code: "unknown_field"}},
+		},
+		{
+			name:   "unknown operator",
+			filter: `{"name.contains": "x"}`,
+			want:   []apperr.FieldError{{Field: "filter.name.contains", Code: "unknown_operator"}},
+		},
+		{
+			name:   "like on a number",
+			filter: `{"age.like": 3}`,
+			want:   []apperr.FieldError{{Field: "filter.age.like", Code: "operator"}},
+		},
+		{
+			name:   "range on an enum",
+			filter: `{"status.gt": "active"}`,
+			want:   []apperr.FieldError{{Field: "filter.status.gt", Code: "operator"}},
+		},
+		{
+			name:   "isnull on a required field",
+			filter: `{"name.isnull": true}`,
+			want:   []apperr.FieldError{{Field: "filter.name.isnull", Code: "operator"}},
+		},
+		{
+			name:   "isnull takes a bool",
+			filter: `{"position.isnull": "yes"}`,
+			want:   []apperr.FieldError{{Field: "filter.position.isnull", Code: "type"}},
+		},
+		{
+			name:   "not an integer",
+			filter: `{"age": "thirty"}`,
+			want:   []apperr.FieldError{{Field: "filter.age", Code: "type"}},
+		},
+		{
+			name:   "not a date",
+			filter: `{"day": "10/03/2024"}`,
+			want:   []apperr.FieldError{{Field: "filter.day", Code: "type"}},
+		},
+		{
+			name:   "not a clock",
+			filter: `{"maxClock": "9:00"}`,
+			want:   []apperr.FieldError{{Field: "filter.maxClock", Code: "type"}},
+		},
+		{
+			name:   "unknown enum value",
+			filter: `{"status": "retired"}`,
+			want:   []apperr.FieldError{{Field: "filter.status", Code: "type"}},
+		},
+		{
+			name:   "objects are not values",
+			filter: `{"name": {"x": 1}}`,
+			want:   []apperr.FieldError{{Field: "filter.name", Code: "type"}},
+		},
+		{
+			name:   "in needs an array",
+			filter: `{"status.in": "active"}`,
+			want:   []apperr.FieldError{{Field: "filter.status.in", Code: "type"}},
+		},
+		{
+			name:   "in needs values",
+			filter: `{"status.in": []}`,
+			want:   []apperr.FieldError{{Field: "filter.status.in", Code: "type"}},
+		},
+		{
+			name:   "bad value inside in",
+			filter: `{"age.in": [1, "two"]}`,
+			want:   []apperr.FieldError{{Field: "filter.age.in[1]", Code: "type"}},
+		},
+		{
+			name:   "between needs two values",
+			filter: `{"age.between": [1, 2, 3]}`,
+			want:   []apperr.FieldError{{Field: "filter.age.between", Code: "type"}},
+		},
+		{
+			name:   "or needs an array",
+			filter: `{"or": {"name": "x"}}`,
+			want:   []apperr.FieldError{{Field: "filter.or", Code: "type"}},
+		},
+		{
+			name:   "empty or branch",
+			filter: `{"or": [{}]}`,
+			want:   []apperr.FieldError{{Field: "filter.or[0]", Code: "required"}},
+		},
+		{
+			name:   "errors inside or name their branch",
+			filter: `{"or": [{"name": "x"}, {"salary": 1}]}`,
+			want:   []apperr.FieldError{{Field: "filter.or[1].salary", Code: "unknown_field"}},
+		},
+		{
+			name:   "or nests too deep",
+			filter: `{"or": [{"or": [{"or": [{"name": "x"}]}]}]}`,
+			want:   []apperr.FieldError{{Field: "filter.or[0].or[0].or", Code: "depth"}},
+		},
+		{
+			name:   "every bad key is reported",
+			filter: `{"age": "x", "salary": 1, "status": "retired"}`,
+			want: []apperr.FieldError{
+				{Field: "filter.age", Code: "type"},
+				{Field: "filter.salary", Code: "unknown_field"},
+				{Field: "filter.status", Code: "type"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := BuildFilterSQL(filterOf(t, tt.filter), testFields, nil)
+			if sql != "" || args != nil {
+				t.Errorf("got sql %q args %v alongside an error", sql, args)
+			}
+			assertFieldErrors(t, err, tt.want)
+		})
+	}
+}
+
+// assertFieldErrors checks that err is a validation error with the fields
+// and codes of want, ignoring the messages.
+func assertFieldErrors(t *testing.T, err error, want []apperr.FieldError) {
+	t.Helper()
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) {
+		t.Fatalf("error = %v, want a validation error", err)
+	}
+	got := make([]apperr.FieldError, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		got[i] = apperr.FieldError{Field: f.Field, Code: f.Code}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("field errors = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildSortSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		sortBy []SortField
+		sql    string
+		errs   []apperr.FieldError
+	}{
+		{name: "none"},
+		{
+			name:   "order defaults to asc",
+			sortBy: []SortField{{Key: "name"}},
+			sql:    "ORDER BY e.name ASC",
+		},
+		{
+			name:   "several keys in priority order",
+			sortBy: []SortField{{Key: "age", Order: "desc"}, {Key: "name", Order: "ASC"}},
+			sql:    "ORDER BY e.age DESC, e.name ASC",
+		},
+		{
+			name:   "unknown key",
+			sortBy: []SortField{{Key: "name"}, {Key: "clock"}},
+			errs:   []apperr.FieldError{{Field: "sort_by[1].key", Code: "unknown_field"}},
+		},
+		{
+			name:   "bad order",
+			sortBy: []SortField{{Key: "name", Order: "up"}},
+			errs:   []apperr.FieldError{{Field: "sort_by[0].order", Code: "oneof"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, err := BuildSortSQL(tt.sortBy, testFields)
+			if tt.errs != nil {
+				assertFieldErrors(t, err, tt.errs)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+		})
+	}
+}
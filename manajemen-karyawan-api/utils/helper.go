@@ -2,23 +2,33 @@ package utils
 
 import (
 	"fmt"
-	"io"
-	"log"
 	"math"
-	"net/http"
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
+	"manajemen-karyawan-api/apperr"
+
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type QueryParams struct {
-	Page    *int              `json:"page"`
-	PerPage *int              `json:"per_page"`
-	SortBy  []SortField       `json:"sort_by"`
-	Filter  map[string]string `json:"filter"`
+	Page    *int        `json:"page"`
+	PerPage *int        `json:"per_page"`
+	SortBy  []SortField `json:"sort_by"`
+	Filter  Filter      `json:"filter"`
+	// Cursor switches to keyset paging: "" for the first page, then the
+	// next_cursor of the previous one.
+	Cursor *string `json:"cursor"`
+	// WithTotal also counts all matching rows on cursor pages; offset pages
+	// are always counted.
+	WithTotal bool `json:"with_total"`
+	// Location is the timezone date filters are read in, set by the caller
+	// rather than the client; UTC when nil.
+	Location *time.Location `json:"-"`
+	// LenientSort drops unknown sort_by keys of offset pages instead of
+	// failing, as the /api list endpoints always did; set by the caller.
+	LenientSort bool `json:"-"`
 }
 
 type MetaParams struct {
@@ -59,6 +69,14 @@ func ReplacePlaceholders(query string) string {
 	return query
 }
 
+// Placeholders returns "?, ?, ..." with n placeholders for IN clauses.
+func Placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
 func BuildMeta(p MetaParams) map[string]interface{} {
 	meta := map[string]interface{}{
 		"total": p.Total,
@@ -77,85 +95,47 @@ func BuildMeta(p MetaParams) map[string]interface{} {
 	return meta
 }
 
-func BindJSONStrict(c *gin.Context, target interface{}) error {
-	body, _ := io.ReadAll(c.Request.Body)
-	log.Println("Raw JSON:", string(body))
-	c.Request.Body = io.NopCloser(strings.NewReader(string(body)))
-
-	if err := c.ShouldBindJSON(target); err != nil {
-		log.Println("Bind error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return err
-	}
-	return nil
-}
-
-func BuildSortSQL(sortBy []SortField, whitelist map[string]string) string {
-	if len(sortBy) == 0 {
-		return ""
-	}
-
-	var clauses []string
-	for _, s := range sortBy {
-		col, ok := whitelist[s.Key]
+// BuildSortSQL turns sortBy into an ORDER BY clause over the columns of
+// fields. Unknown keys or orders fail with a validation error.
+func BuildSortSQL(sortBy []SortField, fields Fields) (string, error) {
+	var (
+		clauses []string
+		errs    []apperr.FieldError
+	)
+	for i, s := range sortBy {
+		field, ok := fields[s.Key]
 		if !ok {
-			log.Printf("Sort field '%s' is not allowed", s.Key)
+			errs = append(errs, apperr.FieldError{
+				Field:   fmt.Sprintf("sort_by[%d].key", i),
+				Code:    "unknown_field",
+				Message: fmt.Sprintf("%s is not a sortable field, use one of: %s", s.Key, strings.Join(fields.names(), ", ")),
+			})
 			continue
 		}
 
 		order := strings.ToUpper(s.Order)
-		if order != "ASC" && order != "DESC" {
+		if order == "" {
 			order = "ASC"
 		}
-
-		clauses = append(clauses, fmt.Sprintf("%s %s", col, order))
-	}
-
-	if len(clauses) == 0 {
-		return ""
-	}
-
-	return "ORDER BY " + strings.Join(clauses, ", ")
-}
-func BuildFilterSQL(filter map[string]string, whitelist map[string]string) (string, []interface{}) {
-	var clauses []string
-	var args []interface{}
-
-	for key, value := range filter {
-		field := key
-		op := "LIKE"
-		arg := "%" + value + "%"
-
-		switch {
-		case strings.HasSuffix(key, ".gte"):
-			field = strings.TrimSuffix(key, ".gte")
-			op = ">="
-			arg = value
-		case strings.HasSuffix(key, ".lte"):
-			field = strings.TrimSuffix(key, ".lte")
-			op = "<="
-			arg = value
-		case strings.HasSuffix(key, ".like"):
-			field = strings.TrimSuffix(key, ".like")
-			op = "LIKE"
-			arg = "%" + value + "%"
-		}
-
-		col, ok := whitelist[field]
-		if !ok {
-			log.Printf("Filter field '%s' is not allowed", key)
+		if order != "ASC" && order != "DESC" {
+			errs = append(errs, apperr.FieldError{
+				Field:   fmt.Sprintf("sort_by[%d].order", i),
+				Code:    "oneof",
+				Message: fmt.Sprintf("sort_by[%d].order must be asc or desc", i),
+			})
 			continue
 		}
 
-		clauses = append(clauses, fmt.Sprintf("%s %s ?", col, op))
-		args = append(args, arg)
+		clauses = append(clauses, fmt.Sprintf("%s %s", field.Column, order))
 	}
 
+	if len(errs) > 0 {
+		return "", apperr.Validation(errs...)
+	}
 	if len(clauses) == 0 {
-		return "", args
+		return "", nil
 	}
-
-	return "AND " + strings.Join(clauses, " AND "), args
+	return "ORDER BY " + strings.Join(clauses, ", "), nil
 }
 
 func BuildPagination(page, perPage *int) Pagination {
@@ -206,23 +186,3 @@ func contains(list []string, item string) bool {
 	}
 	return false
 }
-
-func IsLate(clockIn time.Time, maxRaw string, loc *time.Location) (bool, error) {
-	maxParsed, err := time.ParseInLocation("15:04:05", maxRaw, loc)
-	if err != nil {
-		return false, err
-	}
-	clockInLocal := clockIn.In(loc)
-	clockInOnly := time.Date(0, 1, 1, clockInLocal.Hour(), clockInLocal.Minute(), clockInLocal.Second(), 0, loc)
-	return clockInOnly.After(maxParsed), nil
-}
-
-func IsEarly(clockOut time.Time, maxRaw string, loc *time.Location) (bool, error) {
-	maxParsed, err := time.ParseInLocation("15:04:05", maxRaw, loc)
-	if err != nil {
-		return false, err
-	}
-	clockOutLocal := clockOut.In(loc)
-	clockOutOnly := time.Date(0, 1, 1, clockOutLocal.Hour(), clockOutLocal.Minute(), clockOutLocal.Second(), 0, loc)
-	return clockOutOnly.Before(maxParsed), nil
-}
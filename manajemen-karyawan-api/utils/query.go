@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"manajemen-karyawan-api/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BindQueryParams reads the listing parameters of a GET collection from the
+// query string, the counterpart of the JSON body of the POST .../GetData
+// endpoints:
+//
+//	?page=2&per_page=20&sort=-createdAt,name&status.in=active,resigned&name=budi
+//
+// sort lists keys in priority order, "-" marking descending. cursor and
+// with_total work like in the body. Every other parameter is a filter key:
+// in and between take comma separated values or a repeated parameter,
+// isnull takes true or false, and "or" takes the JSON array of the body's
+// "or". Like BindJSONStrict, a bad value is attached to c and returned.
+func BindQueryParams(c *gin.Context) (QueryParams, error) {
+	var (
+		params QueryParams
+		errs   []apperr.FieldError
+	)
+	fail := func(field, code, message string) {
+		errs = append(errs, apperr.FieldError{Field: field, Code: code, Message: message})
+	}
+
+	query := c.Request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	// Sorted so errors are always reported in the same order
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := query[key]
+		value := values[0]
+		switch key {
+		case "page", "per_page":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				fail(key, "type", key+" must be an integer")
+				continue
+			}
+			if key == "page" {
+				params.Page = &n
+			} else {
+				params.PerPage = &n
+			}
+		case "sort":
+			for _, k := range strings.Split(value, ",") {
+				k = strings.TrimSpace(k)
+				if k == "" {
+					continue
+				}
+				order := "asc"
+				if strings.HasPrefix(k, "-") {
+					k, order = k[1:], "desc"
+				}
+				params.SortBy = append(params.SortBy, SortField{Key: k, Order: order})
+			}
+		case "cursor":
+			params.Cursor = &value
+		case "with_total":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				fail(key, "type", "with_total must be true or false")
+				continue
+			}
+			params.WithTotal = b
+		default:
+			raw, err := filterValue(key, values)
+			if err != nil {
+				fail(key, "type", err.Error())
+				continue
+			}
+			if params.Filter == nil {
+				params.Filter = Filter{}
+			}
+			params.Filter[key] = raw
+		}
+	}
+
+	if len(errs) > 0 {
+		bad := apperr.Validation(errs...)
+		c.Error(bad)
+		return params, bad
+	}
+	return params, nil
+}
+
+// filterValue encodes the query string values of a filter key as the JSON
+// BuildFilterSQL expects; BuildFilterSQL checks their types.
+func filterValue(key string, values []string) (json.RawMessage, error) {
+	_, op, _ := strings.Cut(key, ".")
+	switch {
+	case key == "or":
+		if !json.Valid([]byte(values[0])) {
+			return nil, fmt.Errorf("or must be a JSON array of filter objects")
+		}
+		return json.RawMessage(values[0]), nil
+	case op == opIn || op == opBetween:
+		var list []string
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				list = append(list, strings.TrimSpace(item))
+			}
+		}
+		return json.Marshal(list)
+	case len(values) > 1:
+		name, _, _ := strings.Cut(key, ".")
+		return nil, fmt.Errorf("%s may only be given once, use %s.in for several values", key, name)
+	case op == opIsNull:
+		if b, err := strconv.ParseBool(values[0]); err == nil {
+			return json.Marshal(b)
+		}
+	}
+	return json.Marshal(values[0])
+}
@@ -0,0 +1,170 @@
+// Package validation runs the declarative `validate:"..."` rules of request
+// payloads. One payload type serves both create and update, so rules can
+// depend on the operation: required_on_create only fires for Create, and
+// every other rule skips fields the client left out (nil pointers). A
+// present empty string is validated like any other value; fields where ""
+// means "clear" say so with an alternative, e.g. `validate:"omitempty,clock|len=0"`.
+//
+// Besides the validator built-ins (max, oneof, timezone, ...) these rules
+// are available:
+//
+//	required_on_create  present and non-blank when creating
+//	notblank            not empty or whitespace when present
+//	clock               HH:MM:SS, 00:00:00 to 23:59:59
+//	date                YYYY-MM-DD
+//
+// Rules only look at the payload itself; checks that need the database,
+// such as "departement must exist", stay in the handlers.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"manajemen-karyawan-api/apperr"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type Op int
+
+const (
+	Create Op = iota
+	Update
+)
+
+type opKey struct{}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("validate")
+	v.RegisterTagNameFunc(JSONName)
+
+	v.RegisterValidationCtx("required_on_create", func(ctx context.Context, fl validator.FieldLevel) bool {
+		if op, _ := ctx.Value(opKey{}).(Op); op != Create {
+			return true
+		}
+		s, ok := stringValue(fl.Field())
+		return ok && strings.TrimSpace(s) != ""
+	}, true)
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	v.RegisterValidation("clock", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(ClockLayout, fl.Field().String())
+		return err == nil && len(fl.Field().String()) == len(ClockLayout)
+	})
+	v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(DateLayout, fl.Field().String())
+		return err == nil
+	})
+	return v
+}
+
+const (
+	ClockLayout = "15:04:05"
+	DateLayout  = "2006-01-02"
+)
+
+// stringValue dereferences a string or *string field; ok is false for a nil
+// pointer.
+func stringValue(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return fmt.Sprint(v.Interface()), true
+	}
+	return v.String(), true
+}
+
+// JSONName reports struct fields by their JSON name, so errors name the
+// field the client sent.
+func JSONName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// Struct checks the validate tags of payload for op and returns a
+// validation_failed error listing every bad field, or nil.
+func Struct(ctx context.Context, op Op, payload interface{}) error {
+	err := validate.StructCtx(context.WithValue(ctx, opKey{}, op), payload)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	return apperr.Validation(FieldErrors(verrs)...)
+}
+
+// FieldErrors converts validator errors, from these rules or gin's binding
+// tags, into per-field details.
+func FieldErrors(verrs validator.ValidationErrors) []apperr.FieldError {
+	fields := make([]apperr.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		code := ruleOf(fe)
+		if code == "required_on_create" {
+			code = "required"
+		}
+		fields = append(fields, apperr.FieldError{
+			Field:   fe.Field(),
+			Code:    code,
+			Message: message(fe),
+		})
+	}
+	return fields
+}
+
+// ruleOf names the failed rule; for alternatives such as clock|len=0 that is
+// the first one, the rule the client is expected to satisfy.
+func ruleOf(fe validator.FieldError) string {
+	rule, _, _ := strings.Cut(fe.Tag(), "|")
+	rule, _, _ = strings.Cut(rule, "=")
+	return rule
+}
+
+func message(fe validator.FieldError) string {
+	field := fe.Field()
+	switch ruleOf(fe) {
+	case "required", "required_on_create":
+		return field + " is required"
+	case "notblank":
+		return field + " must not be blank"
+	case "clock":
+		return field + " must be HH:MM:SS"
+	case "date":
+		return field + " must be YYYY-MM-DD"
+	case "timezone":
+		return field + " must be a valid IANA timezone, e.g. Asia/Jakarta"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, fe.Param())
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
+	}
+	return fmt.Sprintf("%s failed %s validation", field, fe.Tag())
+}